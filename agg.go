@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/daemon"
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/Omorfii/aggregator/internal/fetch"
+	"github.com/Omorfii/aggregator/internal/metrics"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultShutdownTimeout = 30 * time.Second
+	defaultFeedsPerFetch   = 5
+
+	// The health server gets this long to answer its last requests once
+	// agg stops.
+	serverShutdownTimeout = 5 * time.Second
+)
+
+// aggStats counts what an agg run did, for the summary logged when it
+// stops.
+type aggStats struct {
+	started  time.Time
+	fetches  int
+	failures int
+	deferred int
+	newPosts int
+	pruned   int
+}
+
+// aggregator is one agg run. A feed that cannot be fetched is logged and
+// skipped; other errors, such as the database failing, stop agg unless it
+// runs in daemon mode.
+type aggregator struct {
+	s      *state
+	stats  aggStats
+	daemon bool
+	feeds  int
+	client *fetch.Client
+	health *daemon.Health
+}
+
+func handlerAgg(s *state, cmd command) error {
+
+	flags := newFlagSet(cmd)
+	shutdownTimeout := flags.Duration("shutdown-timeout", defaultShutdownTimeout, "how long to wait for the current fetches when stopping")
+	daemonMode := flags.Bool("daemon", false, "keep going on database errors and refuse to start twice")
+	pidPath := flags.String("pid-file", "", "PID file preventing a second agg, by default ~/.gator/agg-<profile>.pid in daemon mode")
+	listen := flags.String("listen", "", "serve /healthz, /readyz and /metrics on this address, e.g. 127.0.0.1:9090")
+	feeds := flags.Int("feeds", defaultFeedsPerFetch, "how many of the feeds waiting the longest to fetch at once each time")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	if flags.NArg() <= 0 {
+		return fmt.Errorf("no time given")
+	}
+
+	timeDuration, err := time.ParseDuration(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if timeDuration <= 0 {
+		return fmt.Errorf("time between fetches must be positive")
+	}
+
+	if *feeds <= 0 {
+		return fmt.Errorf("--feeds must be positive")
+	}
+
+	client, err := fetch.New(s.cfg.Fetch)
+	if err != nil {
+		return err
+	}
+
+	a := &aggregator{
+		s:      s,
+		stats:  aggStats{started: time.Now()},
+		daemon: *daemonMode,
+		feeds:  *feeds,
+		client: client,
+	}
+
+	if *daemonMode {
+		if *pidPath == "" {
+			*pidPath, err = daemon.DefaultPIDPath(s.cfg.Profile())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if *pidPath != "" {
+		releasePID, err := daemon.AcquirePIDFile(*pidPath)
+		if err != nil {
+			return err
+		}
+		defer releasePID()
+	}
+
+	if *listen != "" {
+		// A fetch should succeed at least once every few intervals.
+		a.health = daemon.NewHealth(s.store.DB.PingContext, max(3*timeDuration, time.Minute))
+
+		if err := metrics.RegisterQueueLag(a.queueLag); err != nil {
+			return err
+		}
+
+		mux := http.NewServeMux()
+		a.health.Register(mux)
+		metrics.Register(mux)
+
+		listener, err := net.Listen("tcp", *listen)
+		if err != nil {
+			return err
+		}
+
+		server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go server.Serve(listener)
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+			defer cancel()
+			server.Shutdown(ctx)
+		}()
+
+		s.logger.Info("serving health checks and metrics", "address", listener.Addr().String())
+	}
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	// The fetch itself runs on its own context so that a signal lets it
+	// finish; it is only cancelled once the shutdown timeout runs out.
+	work, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+
+	s.logger.Info("collecting feeds", "interval", timeDuration, "pid", os.Getpid())
+
+	ticker := time.NewTicker(timeDuration)
+	defer ticker.Stop()
+
+	for {
+		done := make(chan error, 1)
+		go func() {
+			done <- a.aggregate(work)
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				a.stopped()
+				return err
+			}
+
+		case <-signals.Done():
+			// A second signal now kills the process right away.
+			stopSignals()
+			s.logger.Info("shutting down, waiting for the current fetch (press Ctrl-C again to quit now)", "timeout", *shutdownTimeout)
+
+			timer := time.NewTimer(*shutdownTimeout)
+			select {
+			case err = <-done:
+				timer.Stop()
+			case <-timer.C:
+				s.logger.Warn("shutdown timeout reached, cancelling the current fetch")
+				cancelWork()
+				err = <-done
+			}
+
+			if err != nil && work.Err() == nil {
+				s.logger.Error("last fetch failed", "err", err)
+			}
+			a.stopped()
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-signals.Done():
+			a.stopped()
+			return nil
+		}
+	}
+
+}
+
+// queueLag is how long the feed fetched next has been waiting.
+func (a *aggregator) queueLag(ctx context.Context) (time.Duration, error) {
+
+	feed, err := a.s.db.GetNextFeedToFetch(ctx, time.Now().UTC())
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	// Feed times have no time zone and are written in UTC.
+	now := time.Now().UTC()
+
+	if feed.LastFetchedAt.Valid {
+		return now.Sub(feed.LastFetchedAt.Time), nil
+	}
+
+	return now.Sub(feed.CreatedAt), nil
+}
+
+func (a *aggregator) stopped() {
+	a.s.logger.Info("agg stopped",
+		"fetches", a.stats.fetches,
+		"failures", a.stats.failures,
+		"deferred", a.stats.deferred,
+		"new_posts", a.stats.newPosts,
+		"pruned", a.stats.pruned,
+		"uptime", time.Since(a.stats.started).Round(time.Second))
+}
+
+// fetchResult is how fetching one feed of an aggregate went.
+type fetchResult struct {
+	created  int
+	err      error
+	duration time.Duration
+}
+
+// aggregate fetches the feeds that have waited the longest, a.feeds of
+// them at once, and prunes old posts if configured to. A failed fetch is
+// logged and does not stop agg.
+func (a *aggregator) aggregate(ctx context.Context) error {
+
+	feeds, err := a.claimFeeds(ctx)
+	if err != nil {
+		return a.stopOn(ctx, err, "claiming feeds failed")
+	}
+
+	if len(feeds) == 0 {
+		a.s.logger.Debug("no feed is due for fetching")
+		return nil
+	}
+
+	// The client spaces out and caps the requests to each host, so feeds
+	// on a busy host wait for their turn while the others go ahead.
+	results := make([]fetchResult, len(feeds))
+
+	var wg sync.WaitGroup
+	for i, feed := range feeds {
+		wg.Go(func() {
+			started := time.Now()
+			created, err := a.scrapeFeed(ctx, feed)
+			results[i] = fetchResult{created: created, err: err, duration: time.Since(started)}
+		})
+	}
+	wg.Wait()
+
+	for i, feed := range feeds {
+		if err := a.record(ctx, feed, results[i]); err != nil {
+			return err
+		}
+	}
+
+	if a.s.cfg.Retention.PruneOnAgg {
+		removed, err := prunePosts(a.s, false)
+		if err != nil {
+			return a.stopOn(ctx, err, "pruning posts failed")
+		}
+		a.stats.pruned += removed
+		a.s.logger.Info("pruned posts", "count", removed)
+	}
+
+	return nil
+}
+
+// claimFeeds marks up to a.feeds of the feeds that have waited the longest
+// as fetched, so that the next claim moves on to the others, and returns
+// them.
+func (a *aggregator) claimFeeds(ctx context.Context) ([]database.Feed, error) {
+
+	// next_fetch_at has no time zone, so it is always written and compared
+	// in UTC from here rather than with the database session's clock.
+	now := time.Now().UTC()
+
+	var feeds []database.Feed
+	claimed := make(map[uuid.UUID]bool)
+
+	for len(feeds) < a.feeds {
+
+		feed, err := a.s.db.GetNextFeedToFetch(ctx, now)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		// With fewer feeds than a.feeds, the first one claimed comes round
+		// again.
+		if claimed[feed.ID] {
+			break
+		}
+
+		if err := a.s.db.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{FetchedAt: now, ID: feed.ID}); err != nil {
+			return nil, err
+		}
+
+		claimed[feed.ID] = true
+		feeds = append(feeds, feed)
+	}
+
+	return feeds, nil
+}
+
+// record logs how fetching feed went and counts it. A server asking to
+// come back later defers the feed; other errors than the feed's own stop
+// agg unless it runs in daemon mode.
+func (a *aggregator) record(ctx context.Context, feed database.Feed, result fetchResult) error {
+
+	a.stats.newPosts += result.created
+
+	// A server asking to come back later is not a failure; the feed is
+	// skipped until then.
+	var retryErr *fetch.RetryAfterError
+	if errors.As(result.err, &retryErr) {
+		a.stats.deferred++
+		parameters := database.DelayFeedFetchParams{
+			ID:          feed.ID,
+			NextFetchAt: sql.NullTime{Time: retryErr.Until.UTC(), Valid: true},
+		}
+		if err := a.s.db.DelayFeedFetch(ctx, parameters); err != nil {
+			return a.stopOn(ctx, err, "deferring feed failed", feedAttrs(feed)...)
+		}
+		a.s.logger.Warn("feed deferred", feedAttrs(feed, "status", retryErr.Status, "until", retryErr.Until.Round(time.Second))...)
+		return nil
+	}
+
+	if a.health != nil {
+		a.health.RecordFetch(result.err)
+	}
+	if err := result.err; err != nil {
+		a.stats.failures++
+		var feedErr *feedError
+		if ctx.Err() != nil || (!errors.As(err, &feedErr) && !a.daemon) {
+			return fmt.Errorf("fetching %v failed: %w", feed.Url, err)
+		}
+		a.s.logger.Error("fetch failed", feedAttrs(feed, "duration", result.duration.Round(time.Millisecond), "err", err)...)
+		return nil
+	}
+	a.stats.fetches++
+
+	a.s.logger.Info("fetched feed", feedAttrs(feed, "new_posts", result.created, "duration", result.duration.Round(time.Millisecond))...)
+
+	return nil
+}
+
+// stopOn returns err, an error of gator itself, to stop agg. In daemon
+// mode it is logged with message and args instead, unless agg is already
+// shutting down.
+func (a *aggregator) stopOn(ctx context.Context, err error, message string, args ...any) error {
+
+	if ctx.Err() != nil || !a.daemon {
+		return err
+	}
+
+	a.s.logger.Error(message, append(args, "err", err)...)
+	return nil
+}
+
+// feedError is a feed failing to download or parse, as opposed to an
+// error of gator itself.
+type feedError struct {
+	err error
+}
+
+func (e *feedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *feedError) Unwrap() error {
+	return e.err
+}
+
+// feedAttrs is the fields identifying feed in logs, followed by args.
+func feedAttrs(feed database.Feed, args ...any) []any {
+	return append([]any{"feed_id", feed.ID, "feed", feed.Name, "url", feed.Url}, args...)
+}
+
+// scrapeFeed fetches feedFetched and stores its new posts, returning how
+// many posts were created.
+func (a *aggregator) scrapeFeed(ctx context.Context, feedFetched database.Feed) (int, error) {
+
+	s := a.s
+
+	s.logger.Debug("fetching feed", feedAttrs(feedFetched)...)
+
+	rssFeed, err := fetchFeed(ctx, a.client, feedFetched.Url)
+	if err != nil {
+		return 0, &feedError{err: err}
+	}
+
+	s.logger.Debug("parsed feed", feedAttrs(feedFetched, "items", len(rssFeed.Channel.Item))...)
+
+	rules, err := s.db.GetFilterRulesForFeed(ctx, feedFetched.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	compiledRules := compileRules(s.logger, rules)
+
+	var pubdate sql.NullTime
+
+	created := 0
+
+	for _, item := range rssFeed.Channel.Item {
+
+		description := sql.NullString{
+			String: item.Description,
+			Valid:  item.Description != "",
+		}
+
+		pubtime, err := time.Parse(time.RFC1123Z, item.PubDate)
+		if err != nil {
+			pubdate = sql.NullTime{
+				Time:  time.Time{},
+				Valid: false,
+			}
+		} else {
+			pubdate = sql.NullTime{
+				Time:  pubtime,
+				Valid: true,
+			}
+		}
+
+		parameter := database.CreatePostParams{
+			ID:          uuid.New(),
+			Title:       item.Title,
+			Url:         item.Link,
+			Description: description,
+			PublishedAt: pubdate,
+			FeedID:      feedFetched.ID,
+		}
+
+		post, err := s.db.CreatePost(ctx, parameter)
+		if err != nil {
+			if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint") {
+				continue
+			}
+			return created, err
+		}
+		created++
+		metrics.NewPosts.WithLabelValues(feedFetched.Url).Inc()
+
+		s.logger.Debug("post created", feedAttrs(feedFetched, "post_id", post.ID, "title", post.Title)...)
+
+		_, err = applyRules(s, compiledRules, rulePost{
+			ID:          post.ID,
+			Title:       post.Title,
+			Description: post.Description.String,
+			Url:         post.Url,
+			FeedName:    feedFetched.Name,
+			FeedUrl:     feedFetched.Url,
+		})
+		if err != nil {
+			return created, err
+		}
+
+	}
+
+	return created, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/config"
+	"github.com/Omorfii/aggregator/internal/fetch"
+)
+
+// feedServer serves a feed with one post on every path, taking delay to
+// answer, and records how many requests it served at once.
+type feedServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+}
+
+func newFeedServer(t *testing.T, delay time.Duration) *feedServer {
+
+	server := &feedServer{}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		server.mu.Lock()
+		server.running++
+		server.maxRunning = max(server.maxRunning, server.running)
+		server.mu.Unlock()
+
+		time.Sleep(delay)
+
+		server.mu.Lock()
+		server.running--
+		server.mu.Unlock()
+
+		fmt.Fprintf(w, "<rss><channel><item><title>post</title><link>%v/post</link></item></channel></rss>", server.URL+r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newTestAggregator(t *testing.T, s *state, feeds int, fetchConfig config.FetchConfig) *aggregator {
+
+	t.Helper()
+
+	fetchConfig.Allow = []string{"127.0.0.1"}
+
+	client, err := fetch.New(fetchConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &aggregator{s: s, feeds: feeds, client: client, stats: aggStats{started: time.Now()}}
+}
+
+func TestAggregateFetchesFeedsAtOnce(t *testing.T) {
+
+	s := newTestState(t)
+	user := addUser(t, s, "alice", roleMember, true)
+
+	server := newFeedServer(t, 100*time.Millisecond)
+	for _, name := range []string{"a", "b", "c"} {
+		addFeed(t, s, user, name, server.URL+"/"+name)
+	}
+
+	a := newTestAggregator(t, s, 5, config.FetchConfig{HostInterval: "0s", HostConcurrency: 2})
+
+	if err := a.aggregate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.stats.fetches != 3 || a.stats.newPosts != 3 {
+		t.Errorf("aggregate fetched %d feeds with %d new posts, want 3 and 3", a.stats.fetches, a.stats.newPosts)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if server.maxRunning != 2 {
+		t.Errorf("the host served %d requests at once, want fetch.host_concurrency 2", server.maxRunning)
+	}
+}
+
+func TestClaimFeeds(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		feeds  int
+		claims []int
+	}{
+		{name: "fewer feeds than wanted", feeds: 5, claims: []int{3, 3}},
+		{name: "more feeds than wanted", feeds: 2, claims: []int{2, 2}},
+		{name: "one at a time", feeds: 1, claims: []int{1, 1, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			user := addUser(t, s, "alice", roleMember, true)
+			for _, name := range []string{"a", "b", "c"} {
+				addFeed(t, s, user, name, "https://example.com/"+name)
+			}
+
+			a := newTestAggregator(t, s, test.feeds, config.FetchConfig{})
+
+			seen := make(map[string]bool)
+			for _, want := range test.claims {
+
+				feeds, err := a.claimFeeds(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(feeds) != want {
+					t.Fatalf("claimed %d feeds, want %d", len(feeds), want)
+				}
+
+				claimed := make(map[string]bool)
+				for _, feed := range feeds {
+					if claimed[feed.Name] {
+						t.Errorf("feed %v claimed twice at once", feed.Name)
+					}
+					claimed[feed.Name] = true
+					seen[feed.Name] = true
+				}
+
+				// Claims are a few microseconds apart; keep last_fetched_at
+				// ordered.
+				time.Sleep(time.Millisecond)
+			}
+
+			if len(seen) != 3 {
+				t.Errorf("claims went round %d feeds, want all 3", len(seen))
+			}
+		})
+	}
+}
+
+func TestAggregateErrorsInDaemonMode(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		breakState func(s *state)
+	}{
+		{name: "database gone", breakState: func(s *state) { s.store.Close() }},
+		{name: "pruning fails", breakState: func(s *state) {
+			s.cfg.Retention.PruneOnAgg = true
+			s.cfg.Retention.MaxAge = "forever"
+		}},
+	}
+
+	for _, test := range tests {
+		for _, daemonMode := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%v daemon %v", test.name, daemonMode), func(t *testing.T) {
+
+				s := newTestState(t)
+				user := addUser(t, s, "alice", roleMember, true)
+
+				server := newFeedServer(t, 0)
+				addFeed(t, s, user, "a", server.URL+"/a")
+
+				a := newTestAggregator(t, s, 5, config.FetchConfig{})
+				a.daemon = daemonMode
+
+				test.breakState(s)
+
+				err := a.aggregate(context.Background())
+				if (err != nil) == daemonMode {
+					t.Errorf("aggregate = %v, want an error only outside daemon mode", err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+)
+
+const (
+	minPasswordLength  = 8
+	sessionDuration    = 30 * 24 * time.Hour
+	resetTokenDuration = 7 * 24 * time.Hour
+)
+
+// stdin is shared by every prompt so that answers piped in by a script are
+// not lost to the buffering of an earlier prompt.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readPassword prompts for a password without echoing it when stdin is a
+// terminal, and reads a plain line otherwise.
+func readPassword(prompt string) (string, error) {
+
+	fmt.Print(prompt)
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		password, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		return string(password), err
+	}
+
+	password, err := stdin.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimRight(password, "\r\n"), nil
+}
+
+// readNewPassword asks for a new password twice and returns its hash.
+func readNewPassword() (string, error) {
+
+	password, err := readPassword("New password: ")
+	if err != nil {
+		return "", err
+	}
+
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("password must be at least %v characters", minPasswordLength)
+	}
+
+	again, err := readPassword("Repeat new password: ")
+	if err != nil {
+		return "", err
+	}
+
+	if password != again {
+		return "", fmt.Errorf("passwords do not match")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// checkPassword asks for user's password and compares it to the stored hash.
+func checkPassword(user database.User, prompt string) error {
+
+	password, err := readPassword(prompt)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return fmt.Errorf("wrong password")
+	}
+
+	return nil
+}
+
+// hashToken is what the sessions table stores, so that reading the database
+// is not enough to take over a session.
+func hashToken(token string) string {
+
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken returns a random token for a session or a password reset.
+func newToken() (string, error) {
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+// startSession creates a session for user and saves its token in the config.
+func startSession(s *state, user database.User) error {
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.DeleteExpiredSessions(context.Background(), time.Now()); err != nil {
+		return err
+	}
+
+	parameters := database.CreateSessionParams{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(sessionDuration),
+	}
+
+	if _, err := s.db.CreateSession(context.Background(), parameters); err != nil {
+		return err
+	}
+
+	return s.cfg.SetSession(token)
+}
+
+// issuePasswordReset gives user a one-time token to choose a new password
+// with, replacing any earlier token, and returns it.
+func issuePasswordReset(s *state, user database.User) (string, error) {
+
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.db.DeletePasswordResetsForUser(context.Background(), user.ID); err != nil {
+		return "", err
+	}
+
+	parameters := database.CreatePasswordResetParams{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(resetTokenDuration),
+	}
+
+	if err := s.db.CreatePasswordReset(context.Background(), parameters); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// listUsersWithoutPassword tells who still needs a password after an
+// upgrade. Their reset tokens come from an admin, never from here, since
+// migrate runs without logging in.
+func listUsersWithoutPassword(s *state) error {
+
+	users, err := s.db.GetUsersWithoutPassword(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		fmt.Printf("User %v has no password yet, an admin can let them choose one with: gator passwd --user %v\n", user.Name, user.Name)
+	}
+
+	return nil
+}
+
+// resetPassword checks that token was issued to user, then asks for the new
+// password and sets it.
+func resetPassword(s *state, user database.User, token string) error {
+
+	parameters := database.GetUserFromPasswordResetParams{
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now(),
+	}
+
+	owner, err := s.db.GetUserFromPasswordReset(context.Background(), parameters)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && owner.ID != user.ID) {
+		return fmt.Errorf("reset token is invalid or expired, ask an admin for a new one")
+	} else if err != nil {
+		return err
+	}
+
+	hash, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+
+	return setPassword(s, user, hash)
+}
+
+// setPassword stores hash as user's password and ends their sessions and
+// password resets.
+func setPassword(s *state, user database.User, hash string) error {
+
+	parameters := database.SetUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: hash,
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := s.db.SetUserPassword(context.Background(), parameters); err != nil {
+		return err
+	}
+
+	if err := s.db.DeletePasswordResetsForUser(context.Background(), user.ID); err != nil {
+		return err
+	}
+
+	return s.db.DeleteSessionsForUser(context.Background(), user.ID)
+}
+
+// currentUser returns the user whose session token is in the config.
+func currentUser(s *state) (database.User, error) {
+
+	if s.cfg.SessionToken == "" {
+		return database.User{}, fmt.Errorf("not logged in, run gator login <name>")
+	}
+
+	parameters := database.GetUserFromSessionParams{
+		TokenHash: hashToken(s.cfg.SessionToken),
+		ExpiresAt: time.Now(),
+	}
+
+	user, err := s.db.GetUserFromSession(context.Background(), parameters)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, fmt.Errorf("session expired or invalid, run gator login <name>")
+		}
+		return database.User{}, err
+	}
+
+	return user, nil
+}
+
+func handlerLogout(s *state, cmd command) error {
+
+	if s.cfg.SessionToken == "" {
+		return fmt.Errorf("not logged in")
+	}
+
+	if err := s.db.DeleteSession(context.Background(), hashToken(s.cfg.SessionToken)); err != nil {
+		return err
+	}
+
+	if err := s.cfg.SetSession(""); err != nil {
+		return err
+	}
+
+	fmt.Println("Logged out")
+
+	return nil
+}
+
+// handlerPasswd changes the password of the current user and ends every
+// other session they have. With --user, an admin gives a user a reset
+// token to choose a new password with instead.
+func handlerPasswd(s *state, cmd command, user database.User) error {
+
+	flags := newFlagSet(cmd)
+	userName := flags.String("user", "", "give this user a reset token to choose a new password with (admin only)")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	if *userName != "" {
+		return handlerResetPassword(s, user, *userName)
+	}
+
+	if user.PasswordHash != "" {
+		if err := checkPassword(user, "Current password: "); err != nil {
+			return err
+		}
+	}
+
+	hash, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+
+	if err := setPassword(s, user, hash); err != nil {
+		return err
+	}
+
+	if err := startSession(s, user); err != nil {
+		return err
+	}
+
+	fmt.Println("Password was changed, other sessions were logged out")
+
+	return nil
+}
+
+// handlerResetPassword is passwd --user, for an admin letting a user choose
+// a password, be it their first one or a forgotten one. The admin only
+// passes the token on and never learns the password.
+func handlerResetPassword(s *state, admin database.User, name string) error {
+
+	if admin.Role != roleAdmin {
+		return fmt.Errorf("only admins can reset the password of another user")
+	}
+
+	user, err := s.db.GetUser(context.Background(), name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user %v does not exist", name)
+		}
+		return err
+	}
+
+	token, err := issuePasswordReset(s, user)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%v can choose a new password within %d days with: gator login --reset-token %v %v\n", user.Name, int(resetTokenDuration.Hours()/24), token, user.Name)
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLoginWithResetToken(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		tokenOf string
+		token   string
+		user    string
+		wantErr bool
+	}{
+		{name: "own token", tokenOf: "alice", user: "alice"},
+		{name: "token of another user", tokenOf: "bob", user: "alice", wantErr: true},
+		{name: "unknown token", token: "nope", user: "alice", wantErr: true},
+		{name: "no token and no password", user: "alice", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			for _, name := range []string{"alice", "bob"} {
+				addUser(t, s, name, roleMember, false)
+			}
+
+			token := test.token
+			if test.tokenOf != "" {
+				user, err := s.db.GetUser(context.Background(), test.tokenOf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if token, err = issuePasswordReset(s, user); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			arguments := []string{test.user}
+			if token != "" {
+				arguments = []string{"--reset-token", token, test.user}
+			}
+
+			withInput(t, "new password", "new password")
+
+			err := handlerLogin(s, command{name: "login", arguments: arguments})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("login %v = %v, want error %v", strings.Join(arguments, " "), err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			// The token is used up and the new password works.
+			withInput(t, "new password", "new password")
+			if err := handlerLogin(s, command{name: "login", arguments: arguments}); err == nil {
+				t.Error("reset token worked twice")
+			}
+
+			withInput(t, "new password")
+			if err := handlerLogin(s, command{name: "login", arguments: []string{test.user}}); err != nil {
+				t.Errorf("login with the new password = %v", err)
+			}
+		})
+	}
+}
+
+func TestPasswdUser(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		role    string
+		wantErr bool
+	}{
+		{name: "admin", role: roleAdmin},
+		{name: "member", role: roleMember, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			caller := addUser(t, s, "carol", test.role, true)
+			addUser(t, s, "alice", roleMember, false)
+
+			var err error
+			output := captureOutput(t, func() {
+				err = handlerPasswd(s, command{name: "passwd", arguments: []string{"--user", "alice"}}, caller)
+			})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("passwd --user alice as %v = %v, want error %v", test.role, err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			fields := strings.Fields(output)
+			token := fields[len(fields)-2]
+
+			withInput(t, "new password", "new password")
+			if err := handlerLogin(s, command{name: "login", arguments: []string{"--reset-token", token, "alice"}}); err != nil {
+				t.Errorf("login with the token from passwd --user = %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateUpIssuesNoResetTokens(t *testing.T) {
+
+	s := newTestState(t)
+	addUser(t, s, "alice", roleAdmin, false)
+
+	var err error
+	output := captureOutput(t, func() {
+		err = handlerMigrate(s, command{name: "migrate", arguments: []string{"up"}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resets int
+	if err := s.store.DB.QueryRow("SELECT COUNT(*) FROM password_resets").Scan(&resets); err != nil {
+		t.Fatal(err)
+	}
+
+	if resets != 0 || strings.Contains(output, "--reset-token") {
+		t.Errorf("migrate up issued %d reset tokens and printed %q, want none", resets, output)
+	}
+}
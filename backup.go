@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Omorfii/aggregator/internal/backup"
+	"github.com/Omorfii/aggregator/internal/database"
+)
+
+func handlerBackup(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no backup file given")
+	}
+
+	archive, err := backup.Dump(context.Background(), s.db)
+	if err != nil {
+		return err
+	}
+
+	if err := backup.WriteFile(cmd.arguments[0], archive); err != nil {
+		return err
+	}
+
+	fmt.Printf("Backup written to %v: %d users, %d feeds, %d posts\n", cmd.arguments[0], len(archive.Users), len(archive.Feeds), len(archive.Posts))
+
+	return nil
+}
+
+func handlerRestore(s *state, cmd command, user database.User) error {
+
+	flags := newFlagSet(cmd)
+	onConflict := flags.String("on-conflict", string(backup.Skip), "what to do with rows that already exist: skip or fail")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	if flags.NArg() <= 0 {
+		return fmt.Errorf("no backup file given")
+	}
+
+	mode := backup.OnConflict(*onConflict)
+	if mode != backup.Skip && mode != backup.Fail {
+		return fmt.Errorf("unknown --on-conflict value %v, expected skip or fail", *onConflict)
+	}
+
+	archive, err := backup.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var stats backup.Stats
+
+	err = s.store.InTx(context.Background(), func(q database.Querier) error {
+		var err error
+		stats, err = backup.Restore(context.Background(), q, archive, mode)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("restore failed, nothing was changed: %w", err)
+	}
+
+	tables := make([]string, 0, len(stats.Restored)+len(stats.Skipped))
+	for table := range stats.Restored {
+		tables = append(tables, table)
+	}
+	for table := range stats.Skipped {
+		if _, exists := stats.Restored[table]; !exists {
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		fmt.Printf("%v: %d restored, %d skipped\n", table, stats.Restored[table], stats.Skipped[table])
+	}
+
+	return nil
+}
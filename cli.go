@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type commandInfo struct {
+	usage       string
+	description string
+	handler     func(*state, command) error
+}
+
+type commands struct {
+	handlers    map[string]commandInfo
+	globalFlags *flag.FlagSet
+}
+
+func (c *commands) run(s *state, cmd command) error {
+
+	info, exists := c.handlers[cmd.name]
+	if !exists {
+		return c.unknownCommand(cmd.name)
+	}
+
+	cmd.usage = info.usage
+
+	if cmd.name != completeCommand && wantsHelp(cmd.arguments) {
+		c.printCommandHelp(cmd.name)
+		return nil
+	}
+
+	return info.handler(s, cmd)
+
+}
+
+// register adds a command. usage is the command line without the leading
+// "gator", e.g. "follow <url>", and description a one line summary; both
+// are shown by help.
+func (c *commands) register(name string, usage string, description string, f func(*state, command) error) error {
+
+	if _, exists := c.handlers[name]; exists {
+		return fmt.Errorf("handler for command %s already exists", name)
+	}
+	c.handlers[name] = commandInfo{
+		usage:       usage,
+		description: description,
+		handler:     f,
+	}
+	return nil
+}
+
+func (c *commands) exists(name string) bool {
+
+	_, exists := c.handlers[name]
+
+	return exists
+}
+
+func (c *commands) names() []string {
+
+	names := make([]string, 0, len(c.handlers))
+	for name := range c.handlers {
+		if name == completeCommand {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// unknownCommand returns the error for a command that is not registered,
+// suggesting the closest one when the name looks like a typo.
+func (c *commands) unknownCommand(name string) error {
+
+	best := ""
+	bestDistance := 3
+
+	for _, candidate := range c.names() {
+		distance := editDistance(name, candidate)
+		if distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	if best != "" {
+		return fmt.Errorf("unknown command %v, did you mean %v?", name, best)
+	}
+
+	return fmt.Errorf("unknown command %v, run gator help to list commands", name)
+}
+
+func (c *commands) printHelp() {
+
+	fmt.Println("usage: gator [global flags] <command> [arguments]")
+	fmt.Println()
+	fmt.Println("commands:")
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, name := range c.names() {
+		fmt.Fprintf(writer, "  %v\t%v\n", name, c.handlers[name].description)
+	}
+	writer.Flush()
+
+	if c.globalFlags != nil {
+		fmt.Println()
+		fmt.Println("global flags, accepted before or after the command:")
+		c.globalFlags.SetOutput(os.Stdout)
+		c.globalFlags.PrintDefaults()
+		c.globalFlags.SetOutput(os.Stderr)
+	}
+
+	fmt.Println()
+	fmt.Println("run gator help <command> for the arguments of a command")
+}
+
+func (c *commands) printCommandHelp(name string) {
+
+	info := c.handlers[name]
+
+	fmt.Printf("usage: gator %v\n", info.usage)
+	fmt.Println()
+	fmt.Println(info.description)
+}
+
+// handlerHelp is registered like any other command but needs the registry,
+// so it is built from it.
+func (c *commands) handlerHelp(s *state, cmd command) error {
+
+	if len(cmd.arguments) <= 0 {
+		c.printHelp()
+		return nil
+	}
+
+	if !c.exists(cmd.arguments[0]) {
+		return c.unknownCommand(cmd.arguments[0])
+	}
+
+	c.printCommandHelp(cmd.arguments[0])
+
+	return nil
+}
+
+// newFlagSet returns the flag set a handler parses its arguments with. Its
+// usage message is the one the command was registered with.
+func newFlagSet(cmd command) *flag.FlagSet {
+
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: gator %v\n", cmd.usage)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+func wantsHelp(arguments []string) bool {
+
+	for _, argument := range arguments {
+		if argument == "--" {
+			return false
+		}
+		if argument == "-h" || argument == "-help" || argument == "--help" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseGlobalFlags parses the global flags wherever they appear, so that
+// "gator browse --format json" works like "gator --format json browse",
+// and returns the remaining arguments.
+func parseGlobalFlags(globalFlags *flag.FlagSet, arguments []string) ([]string, error) {
+
+	var globals, rest []string
+
+	for i := 0; i < len(arguments); i++ {
+
+		argument := arguments[i]
+
+		if argument == "--" {
+			rest = append(rest, arguments[i+1:]...)
+			break
+		}
+
+		if !strings.HasPrefix(argument, "-") {
+			rest = append(rest, argument)
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(argument, "-"), "=")
+
+		globalFlag := globalFlags.Lookup(name)
+		if globalFlag == nil {
+			rest = append(rest, argument)
+			continue
+		}
+
+		globals = append(globals, argument)
+
+		if isBoolFlag(globalFlag) || hasValue {
+			continue
+		}
+
+		if i+1 < len(arguments) {
+			globals = append(globals, arguments[i+1])
+			i++
+		}
+	}
+
+	if err := globalFlags.Parse(globals); err != nil {
+		return nil, err
+	}
+
+	return rest, nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// printJSON writes v to stdout for --format json.
+func printJSON(v any) error {
+
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(jsonData))
+
+	return nil
+}
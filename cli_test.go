@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseGlobalFlags(t *testing.T) {
+
+	tests := []struct {
+		arguments   string
+		wantRest    string
+		wantProfile string
+		wantVerbose bool
+		wantErr     bool
+	}{
+		{arguments: "browse 5", wantRest: "browse 5"},
+		{arguments: "--profile work browse", wantRest: "browse", wantProfile: "work"},
+		{arguments: "browse --profile=work 5", wantRest: "browse 5", wantProfile: "work"},
+		{arguments: "-verbose agg 1m", wantRest: "agg 1m", wantVerbose: true},
+		{arguments: "agg --daemon 1m --verbose", wantRest: "agg --daemon 1m", wantVerbose: true},
+		{arguments: "addfeed -- --profile x", wantRest: "addfeed --profile x"},
+		{arguments: "browse --profile", wantRest: "browse", wantErr: true},
+		{arguments: "--verbose=maybe browse", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.arguments, func(t *testing.T) {
+
+			globalFlags := flag.NewFlagSet("gator", flag.ContinueOnError)
+			globalFlags.SetOutput(io.Discard)
+			profile := globalFlags.String("profile", "", "")
+			verbose := globalFlags.Bool("verbose", false, "")
+
+			rest, err := parseGlobalFlags(globalFlags, strings.Fields(test.arguments))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseGlobalFlags(%v) = %v, want error %v", test.arguments, err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			if !slices.Equal(rest, strings.Fields(test.wantRest)) || *profile != test.wantProfile || *verbose != test.wantVerbose {
+				t.Errorf("parseGlobalFlags(%v) left %q with profile %q and verbose %v, want %q, %q and %v",
+					test.arguments, rest, *profile, *verbose, test.wantRest, test.wantProfile, test.wantVerbose)
+			}
+		})
+	}
+}
+
+func TestWantsHelp(t *testing.T) {
+
+	tests := []struct {
+		arguments string
+		want      bool
+	}{
+		{arguments: "https://example.com/feed"},
+		{arguments: "-h", want: true},
+		{arguments: "--name x --help", want: true},
+		{arguments: "-- -h"},
+	}
+
+	for _, test := range tests {
+		if got := wantsHelp(strings.Fields(test.arguments)); got != test.want {
+			t.Errorf("wantsHelp(%v) = %v, want %v", test.arguments, got, test.want)
+		}
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+
+	registry := commands{handlers: make(map[string]commandInfo)}
+	for _, name := range []string{"browse", "follow", "following", "register"} {
+		registry.register(name, name, name, nil)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "brwose", want: "did you mean browse?"},
+		{name: "folow", want: "did you mean follow?"},
+		{name: "delete", want: "run gator help"},
+	}
+
+	for _, test := range tests {
+		err := registry.run(&state{}, command{name: test.name})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("running %v = %v, want it to say %q", test.name, err, test.want)
+		}
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+
+	registry := commands{handlers: make(map[string]commandInfo)}
+
+	if err := registry.register("browse", "browse", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.register("browse", "browse", "", nil); err == nil {
+		t.Error("browse was registered twice")
+	}
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/Omorfii/aggregator/internal/config"
+	"github.com/Omorfii/aggregator/internal/storage"
+)
+
+// completeCommand is the hidden command the completion scripts call to
+// complete the arguments of a command.
+const completeCommand = "__complete"
+
+type candidate struct {
+	value       string
+	description string
+}
+
+// completer returns the candidates for an argument, given the arguments
+// before it. Completers that need the database return nothing when it
+// could not be opened.
+type completer func(s *state, arguments []string) []candidate
+
+func words(values ...string) completer {
+
+	return func(s *state, arguments []string) []candidate {
+		candidates := make([]candidate, 0, len(values))
+		for _, value := range values {
+			candidates = append(candidates, candidate{value: value})
+		}
+		return candidates
+	}
+}
+
+func completeFeedURLs(s *state, arguments []string) []candidate {
+
+	if s.db == nil {
+		return nil
+	}
+
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		return nil
+	}
+
+	candidates := make([]candidate, 0, len(feeds))
+	for _, feed := range feeds {
+		candidates = append(candidates, candidate{value: feed.Url, description: feed.Name})
+	}
+
+	return candidates
+}
+
+func completeFollowedFeedURLs(s *state, arguments []string) []candidate {
+
+	if s.db == nil {
+		return nil
+	}
+
+	user, err := currentUser(s)
+	if err != nil {
+		return nil
+	}
+
+	feeds, err := s.db.GetFollowedFeedsForUser(context.Background(), user.ID)
+	if err != nil {
+		return nil
+	}
+
+	candidates := make([]candidate, 0, len(feeds))
+	for _, feed := range feeds {
+		candidates = append(candidates, candidate{value: feed.Url, description: feed.DisplayName})
+	}
+
+	return candidates
+}
+
+func completeUserNames(s *state, arguments []string) []candidate {
+
+	if s.db == nil {
+		return nil
+	}
+
+	users, err := s.db.GetUsers(context.Background())
+	if err != nil {
+		return nil
+	}
+
+	candidates := make([]candidate, 0, len(users))
+	for _, user := range users {
+		candidates = append(candidates, candidate{value: user.Name, description: user.Role})
+	}
+
+	return candidates
+}
+
+func completeFolders(s *state, arguments []string) []candidate {
+
+	if s.db == nil {
+		return nil
+	}
+
+	user, err := currentUser(s)
+	if err != nil {
+		return nil
+	}
+
+	folders, err := s.db.GetFoldersForUser(context.Background(), user.ID)
+	if err != nil {
+		return nil
+	}
+
+	candidates := make([]candidate, 0, len(folders))
+	for _, folder := range folders {
+		candidates = append(candidates, candidate{value: folder.Name})
+	}
+
+	return candidates
+}
+
+func completeTags(s *state, arguments []string) []candidate {
+
+	if s.db == nil {
+		return nil
+	}
+
+	user, err := currentUser(s)
+	if err != nil {
+		return nil
+	}
+
+	tags, err := s.db.GetTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return nil
+	}
+
+	candidates := make([]candidate, 0, len(tags))
+	for _, tag := range tags {
+		candidates = append(candidates, candidate{value: tag.Name, description: fmt.Sprintf("%d posts", tag.PostCount)})
+	}
+
+	return candidates
+}
+
+func completeProfiles(s *state, arguments []string) []candidate {
+
+	names, _, err := config.Profiles()
+	if err != nil {
+		return nil
+	}
+
+	return words(names...)(s, arguments)
+}
+
+// completeProfileName completes the profile after "profile use" and
+// "profile remove".
+func completeProfileName(s *state, arguments []string) []candidate {
+
+	if arguments[0] != "use" && arguments[0] != "remove" {
+		return nil
+	}
+
+	return completeProfiles(s, arguments)
+}
+
+// completeConfigKey completes the key after "config get" and "config set".
+func completeConfigKey(s *state, arguments []string) []candidate {
+
+	if arguments[0] != "get" && arguments[0] != "set" {
+		return nil
+	}
+
+	return words(config.Keys...)(s, arguments)
+}
+
+// argumentCompleters lists, for each command, how to complete its
+// positional arguments in order.
+var argumentCompleters = map[string][]completer{
+	"login":        {completeUserNames},
+	"promote":      {completeUserNames},
+	"demote":       {completeUserNames},
+	"deleteuser":   {completeUserNames},
+	"follow":       {completeFeedURLs},
+	"editfeed":     {completeFeedURLs},
+	"deletefeed":   {completeFeedURLs},
+	"unfollow":     {completeFollowedFeedURLs},
+	"rename":       {completeFollowedFeedURLs},
+	"movefeed":     {completeFollowedFeedURLs, completeFolders},
+	"following":    {completeFolders},
+	"renamefolder": {completeFolders},
+	"deletefolder": {completeFolders},
+	"tag":          {nil, completeTags},
+	"untag":        {nil, completeTags},
+	"migrate":      {words("up", "down", "status")},
+	"completion":   {words("bash", "zsh", "fish")},
+	"profile":      {words("list", "use", "add", "remove"), completeProfileName},
+	"config":       {words("get", "set", "show"), completeConfigKey},
+}
+
+// flagValueCompleters completes the value following a flag.
+var flagValueCompleters = map[string]completer{
+	"folder":      completeFolders,
+	"tag":         completeTags,
+	"user":        completeUserNames,
+	"profile":     completeProfiles,
+	"format":      words(formatText, formatJSON),
+	"log-format":  words(formatText, formatJSON),
+	"on-conflict": words("skip", "fail"),
+}
+
+var usageFlag = regexp.MustCompile(`--[a-z-]+`)
+
+// complete returns the candidates for current, the word being typed, after
+// the arguments already given to the command.
+func (c *commands) complete(s *state, name string, arguments []string, current string) []candidate {
+
+	if name == "help" && len(arguments) == 0 {
+		candidates := make([]candidate, 0, len(c.handlers))
+		for _, commandName := range c.names() {
+			candidates = append(candidates, candidate{value: commandName, description: c.handlers[commandName].description})
+		}
+		return candidates
+	}
+
+	if strings.HasPrefix(current, "-") {
+		var candidates []candidate
+		for _, flagName := range usageFlag.FindAllString(c.handlers[name].usage, -1) {
+			candidates = append(candidates, candidate{value: flagName})
+		}
+		c.globalFlags.VisitAll(func(f *flag.Flag) {
+			candidates = append(candidates, candidate{value: "--" + f.Name, description: f.Usage})
+		})
+		return candidates
+	}
+
+	if len(arguments) > 0 {
+		previous := arguments[len(arguments)-1]
+		if strings.HasPrefix(previous, "--") && !strings.Contains(previous, "=") {
+			if valueCompleter, exists := flagValueCompleters[strings.TrimPrefix(previous, "--")]; exists {
+				return valueCompleter(s, arguments)
+			}
+		}
+	}
+
+	var positional []string
+	for _, argument := range arguments {
+		if !strings.HasPrefix(argument, "-") {
+			positional = append(positional, argument)
+		}
+	}
+
+	completers := argumentCompleters[name]
+	if len(positional) >= len(completers) || completers[len(positional)] == nil {
+		return nil
+	}
+
+	return completers[len(positional)](s, positional)
+}
+
+// handlerComplete is called by the completion scripts as
+// "gator __complete <words...> <current word>" with the words typed after
+// "gator", and prints one candidate per line, with a tab before its
+// description.
+func (c *commands) handlerComplete(s *state, cmd command) error {
+
+	if len(cmd.arguments) < 1 {
+		return nil
+	}
+
+	current := cmd.arguments[len(cmd.arguments)-1]
+	typed := cmd.arguments[:len(cmd.arguments)-1]
+
+	var candidates []candidate
+
+	// Skip the global flags given before the command.
+	for len(typed) > 0 && strings.HasPrefix(typed[0], "-") {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(typed[0], "-"), "=")
+		globalFlag := c.globalFlags.Lookup(name)
+		if globalFlag == nil || isBoolFlag(globalFlag) || hasValue {
+			typed = typed[1:]
+			continue
+		}
+		if len(typed) == 1 {
+			if valueCompleter, exists := flagValueCompleters[name]; exists {
+				candidates = valueCompleter(s, nil)
+			}
+		}
+		typed = typed[min(2, len(typed)):]
+	}
+
+	switch {
+	case candidates != nil:
+	case len(typed) == 0:
+		candidates = c.complete(s, "help", nil, current)
+	case c.exists(typed[0]):
+		candidates = c.complete(s, typed[0], typed[1:], current)
+	}
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate.value, current) {
+			fmt.Printf("%v\t%v\n", candidate.value, candidate.description)
+		}
+	}
+
+	return nil
+}
+
+// newCompletionState is the state __complete runs with. Completion runs on
+// every tab press and must print nothing but candidates, so the config and
+// database are only used if they already exist, nothing is created, and
+// any error leaves them out.
+func newCompletionState(profile string, overrides map[string]string, logger *slog.Logger) *state {
+
+	completionState := &state{format: formatText, logger: logger}
+
+	cfg, err := config.ReadExisting(profile, overrides)
+	if err != nil {
+		return completionState
+	}
+	completionState.cfg = &cfg
+
+	store, err := storage.OpenExisting(cfg.Url)
+	if err != nil {
+		return completionState
+	}
+	completionState.db = store
+	completionState.store = store
+
+	return completionState
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for gator, load with: source <(gator completion bash)
+_gator() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        _get_comp_words_by_ref -n : cur words cword
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+    fi
+
+    if [[ $cword -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "{{range .}}{{.Name}} {{end}}" -- "$cur"))
+    else
+        local IFS=$'\n'
+        COMPREPLY=($(gator __complete "${words[@]:1:cword-1}" "$cur" 2>/dev/null | cut -f1))
+    fi
+
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -F _gator gator
+`,
+	"zsh": `#compdef gator
+# zsh completion for gator, load with: source <(gator completion zsh)
+_gator() {
+    local -a candidates
+    local line
+    if (( CURRENT == 2 )); then
+        candidates=({{range .}}{{printf "%q" (print .Name ":" .Description)}} {{end}})
+    else
+        for line in "${(@f)$(gator __complete "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}"; do
+            [[ -n $line ]] || continue
+            candidates+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+        done
+    fi
+    _describe gator candidates
+}
+compdef _gator gator
+`,
+	"fish": `# fish completion for gator, load with: gator completion fish | source
+function __gator_complete
+    set -l tokens (commandline -opc)
+    gator __complete $tokens[2..-1] (commandline -ct) 2>/dev/null
+end
+
+complete -c gator -f
+{{range .}}complete -c gator -n __fish_use_subcommand -a {{.Name}} -d {{printf "%q" .Description}}
+{{end}}complete -c gator -n 'not __fish_use_subcommand' -a '(__gator_complete)'
+`,
+}
+
+func (c *commands) handlerCompletion(s *state, cmd command) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("usage: completion bash|zsh|fish")
+	}
+
+	script, exists := completionScripts[cmd.arguments[0]]
+	if !exists {
+		return fmt.Errorf("unknown shell %v, expected bash, zsh or fish", cmd.arguments[0])
+	}
+
+	type commandEntry struct {
+		Name        string
+		Description string
+	}
+
+	var entries []commandEntry
+	for _, name := range c.names() {
+		entries = append(entries, commandEntry{Name: name, Description: c.handlers[name].description})
+	}
+
+	tmpl, err := template.New(cmd.arguments[0]).Parse(script)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(os.Stdout, entries)
+}
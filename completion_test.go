@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Omorfii/aggregator/internal/config"
+)
+
+func TestBashCompletionOfCommands(t *testing.T) {
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	registry := commands{handlers: make(map[string]commandInfo), globalFlags: flag.NewFlagSet("gator", flag.ContinueOnError)}
+	for _, name := range []string{"addfeed", "addfolder", "agg", "browse"} {
+		registry.register(name, name, "does "+name, nil)
+	}
+
+	var scriptErr error
+	script := captureOutput(t, func() {
+		scriptErr = registry.handlerCompletion(&state{}, command{name: "completion", arguments: []string{"bash"}})
+	})
+	if scriptErr != nil {
+		t.Fatal(scriptErr)
+	}
+
+	scriptPath := filepath.Join(t.TempDir(), "gator.bash")
+	if err := os.WriteFile(scriptPath, []byte(script), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		current string
+		want    []string
+	}{
+		{current: "ad", want: []string{"addfeed", "addfolder"}},
+		{current: "agg", want: []string{"agg"}},
+		{current: "", want: []string{"addfeed", "addfolder", "agg", "browse"}},
+		{current: "zz"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.current, func(t *testing.T) {
+
+			run := `source "$1"; COMP_WORDS=(gator "$2"); COMP_CWORD=1; _gator; for reply in "${COMPREPLY[@]}"; do echo "$reply"; done`
+			output, err := exec.Command(bash, "-c", run, "bash", scriptPath, test.current).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := strings.Fields(string(output))
+			slices.Sort(got)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("completing %q gave %q, want %q", test.current, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompletionStateCreatesNoFiles(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+	}{
+		{name: "default database"},
+		{name: "database from a flag", overrides: map[string]string{"db_url": "sqlite://~/elsewhere/gator.db"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+			t.Setenv("GATOR_CONFIG", "")
+			t.Setenv("GATOR_DB_URL", "")
+			os.Unsetenv("GATOR_DB_URL")
+
+			completionState := newCompletionState("", test.overrides, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if completionState.store != nil {
+				completionState.store.Close()
+				t.Error("completion opened a database that does not exist")
+			}
+
+			filepath.WalkDir(home, func(path string, entry fs.DirEntry, err error) error {
+				if path != home {
+					t.Errorf("completion created %v", path)
+				}
+				return err
+			})
+		})
+	}
+}
+
+func TestCompletionStateUsesExistingDatabase(t *testing.T) {
+
+	s := newTestState(t)
+	addUser(t, s, "alice", roleMember, true)
+
+	// newTestState's database is a file in a temporary directory; point a
+	// config at it the way a user would.
+	dbPath := ""
+	if err := s.store.DB.QueryRow("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&dbPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Save(s.cfg.Profile(), "db_url", "sqlite://"+dbPath); err != nil {
+		t.Fatal(err)
+	}
+
+	completionState := newCompletionState("", nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if completionState.store == nil {
+		t.Fatal("completion did not open the configured database")
+	}
+	defer completionState.store.Close()
+
+	users, err := completionState.db.GetUsers(t.Context())
+	if err != nil || len(users) != 1 {
+		t.Errorf("completion sees users %v, %v, want alice", users, err)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+
+	"github.com/Omorfii/aggregator/internal/config"
+)
+
+func handlerConfig(s *state, cmd command) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("usage: config get <key> | set <key> <value> | show")
+	}
+
+	action := cmd.arguments[0]
+	arguments := cmd.arguments[1:]
+
+	switch action {
+	case "get":
+		if len(arguments) <= 0 {
+			return fmt.Errorf("no config key given")
+		}
+		value, err := s.cfg.Get(arguments[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+
+	case "set":
+		if len(arguments) < 2 {
+			return fmt.Errorf("usage: config set <key> <value>")
+		}
+		if err := config.Save(s.cfg.Profile(), arguments[0], arguments[1]); err != nil {
+			return err
+		}
+		fmt.Printf("%v was set in profile %v\n", arguments[0], s.cfg.Profile())
+
+	case "show":
+		path, err := config.Path()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("config file: %v\n", path)
+		fmt.Printf("profile: %v\n", s.cfg.Profile())
+		for _, key := range config.Keys {
+			value, err := s.cfg.Get(key)
+			if err != nil {
+				return err
+			}
+			if key == "session_token" && value != "" {
+				value = "(hidden)"
+			}
+			if key == "db_url" || key == "fetch.proxy" {
+				value = redactPassword(value)
+			}
+			fmt.Printf("%v = %v\n", key, value)
+		}
+		feedURLs := make([]string, 0, len(s.cfg.Retention.Feeds))
+		for feedURL := range s.cfg.Retention.Feeds {
+			feedURLs = append(feedURLs, feedURL)
+		}
+		sort.Strings(feedURLs)
+		for _, feedURL := range feedURLs {
+			policy := s.cfg.Retention.Feeds[feedURL]
+			fmt.Printf("retention for %v: max_age=%v max_posts=%v\n", feedURL, policy.MaxAge, policy.MaxPosts)
+		}
+
+	default:
+		return fmt.Errorf("unknown config action %v, expected get, set or show", action)
+	}
+
+	return nil
+}
+
+// connectionPassword is the password of a key=value Postgres connection
+// string, e.g. host=localhost password=secret.
+var connectionPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// redactPassword hides the password in a url or connection string, the
+// way config show hides session_token.
+func redactPassword(value string) string {
+
+	if parsed, err := url.Parse(value); err == nil && parsed.User != nil {
+		return parsed.Redacted()
+	}
+
+	return connectionPassword.ReplaceAllString(value, "${1}xxxxx")
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Omorfii/aggregator/internal/database"
+)
+
+// getManagedFeed returns the feed with the given url if user may edit or
+// delete it: they added it, or they are an admin.
+func getManagedFeed(s *state, user database.User, feedURL string) (database.Feed, error) {
+
+	feed, err := s.db.GetFeed(context.Background(), feedURL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Feed{}, fmt.Errorf("feed does not exist")
+		}
+		return database.Feed{}, err
+	}
+
+	if feed.UserID != user.ID && user.Role != roleAdmin {
+		return database.Feed{}, fmt.Errorf("only the user who added %v or an admin can change it", feed.Name)
+	}
+
+	return feed, nil
+}
+
+func handlerEditFeed(s *state, cmd command, user database.User) error {
+
+	flags := newFlagSet(cmd)
+	name := flags.String("name", "", "new name of the feed")
+	url := flags.String("url", "", "new url of the feed")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	if flags.NArg() <= 0 {
+		return fmt.Errorf("usage: editfeed [--name <name>] [--url <url>] <feed url>")
+	}
+
+	if *name == "" && *url == "" {
+		return fmt.Errorf("nothing to change, give --name or --url")
+	}
+
+	feed, err := getManagedFeed(s, user, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	parameters := database.UpdateFeedParams{
+		ID:   feed.ID,
+		Name: feed.Name,
+		Url:  feed.Url,
+	}
+
+	if *name != "" {
+		parameters.Name = *name
+	}
+
+	if *url != "" {
+		if _, err := s.db.GetFeed(context.Background(), *url); err == nil {
+			return fmt.Errorf("a feed with url %v already exists", *url)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		parameters.Url = *url
+	}
+
+	feed, err = s.db.UpdateFeed(context.Background(), parameters)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Feed was updated: %v\n  %v\n", feed.Name, feed.Url)
+
+	return nil
+}
+
+// handlerDeleteFeed deletes a feed with its posts when nobody but its owner
+// follows it. Otherwise the owner stops following it and the feed is handed
+// to the follower who followed it first.
+func handlerDeleteFeed(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no feed url given")
+	}
+
+	feed, err := getManagedFeed(s, user, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	// Handing a feed over is for the user who added it giving it up; an
+	// admin removing someone else's feed removes it for everyone.
+	if feed.UserID != user.ID {
+		if err := s.db.DeleteFeed(context.Background(), feed.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Feed %v and its posts were deleted\n", feed.Name)
+		return nil
+	}
+
+	var newOwner database.User
+
+	err = s.store.InTx(context.Background(), func(q database.Querier) error {
+		var err error
+		newOwner, err = handOverFeed(q, feed)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if newOwner.Name != "" {
+		fmt.Printf("%v is still followed by others, it now belongs to %v\n", feed.Name, newOwner.Name)
+		return nil
+	}
+
+	fmt.Printf("Feed %v and its posts were deleted\n", feed.Name)
+
+	return nil
+}
+
+// handOverFeed takes feed away from the user who added it. It goes to its
+// earliest other follower, or is deleted with its posts when nobody else
+// follows it. The new owner is returned, or a zero User if it was deleted.
+func handOverFeed(q database.Querier, feed database.Feed) (database.User, error) {
+
+	feedFollows, err := q.GetFeedFollowsForFeed(context.Background(), feed.ID)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	var heir *database.FeedFollow
+	for i := range feedFollows {
+		if feedFollows[i].UserID != feed.UserID {
+			heir = &feedFollows[i]
+			break
+		}
+	}
+
+	if heir == nil {
+		return database.User{}, q.DeleteFeed(context.Background(), feed.ID)
+	}
+
+	parameters := database.SetFeedOwnerParams{
+		ID:     feed.ID,
+		UserID: heir.UserID,
+	}
+
+	if err := q.SetFeedOwner(context.Background(), parameters); err != nil {
+		return database.User{}, err
+	}
+
+	unfollowParameters := database.UnfollowFeedParams{
+		UserID: feed.UserID,
+		FeedID: feed.ID,
+	}
+
+	if err := q.UnfollowFeed(context.Background(), unfollowParameters); err != nil {
+		return database.User{}, err
+	}
+
+	return q.GetUserFromID(context.Background(), heir.UserID)
+}
+
+// deleteUser deletes user after handing the feeds they added over to
+// their other followers, so that deleting an account does not take feeds
+// and posts away from everyone else.
+func deleteUser(s *state, user database.User) error {
+
+	var handedOver []string
+
+	err := s.store.InTx(context.Background(), func(q database.Querier) error {
+
+		feeds, err := q.GetFeedsForUser(context.Background(), user.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, feed := range feeds {
+			newOwner, err := handOverFeed(q, feed)
+			if err != nil {
+				return err
+			}
+			if newOwner.Name != "" {
+				handedOver = append(handedOver, fmt.Sprintf("Feed %v now belongs to %v", feed.Name, newOwner.Name))
+			}
+		}
+
+		return q.DeleteUser(context.Background(), user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, line := range handedOver {
+		fmt.Println(line)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestDeleteFeed(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		callerRole    string
+		callerIsOwner bool
+		otherFollower bool
+		wantErr       bool
+		wantOwner     string
+	}{
+		{name: "owner alone", callerIsOwner: true, callerRole: roleMember},
+		{name: "owner with another follower", callerIsOwner: true, callerRole: roleMember, otherFollower: true, wantOwner: "bob"},
+		{name: "admin on someone else's feed", callerRole: roleAdmin, otherFollower: true},
+		{name: "member on someone else's feed", callerRole: roleMember, wantErr: true, wantOwner: "alice"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			alice := addUser(t, s, "alice", roleMember, true)
+			bob := addUser(t, s, "bob", roleMember, true)
+
+			feed := addFeed(t, s, alice, "a", "https://example.com/a")
+			follow(t, s, alice, feed)
+			if test.otherFollower {
+				follow(t, s, bob, feed)
+			}
+
+			caller := alice
+			if !test.callerIsOwner {
+				caller = addUser(t, s, "carol", test.callerRole, true)
+			}
+
+			var err error
+			captureOutput(t, func() {
+				err = handlerDeleteFeed(s, command{name: "deletefeed", arguments: []string{feed.Url}}, caller)
+			})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("deletefeed = %v, want error %v", err, test.wantErr)
+			}
+
+			feed, err = s.db.GetFeed(context.Background(), feed.Url)
+			if test.wantOwner == "" {
+				if !errors.Is(err, sql.ErrNoRows) {
+					t.Errorf("feed still exists (%v), want it deleted", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			owner, err := s.db.GetUserFromID(context.Background(), feed.UserID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner.Name != test.wantOwner {
+				t.Errorf("feed belongs to %v, want %v", owner.Name, test.wantOwner)
+			}
+
+			// Only giving a feed away unfollows it.
+			follows, err := s.db.GetFeedFollowsForUser(context.Background(), alice.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantFollows := 1
+			if test.callerIsOwner {
+				wantFollows = 0
+			}
+			if len(follows) != wantFollows {
+				t.Errorf("alice follows %d feeds, want %d", len(follows), wantFollows)
+			}
+		})
+	}
+}
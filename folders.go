@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func getFolderFromName(s *state, user database.User, name string) (database.Folder, error) {
+
+	parameter := database.GetFolderParams{
+		UserID: user.ID,
+		Name:   name,
+	}
+
+	folder, err := s.db.GetFolder(context.Background(), parameter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Folder{}, fmt.Errorf("folder %v does not exist", name)
+	}
+
+	return folder, err
+}
+
+func handlerAddFolder(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no folder name given")
+	}
+
+	parameter := database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Name:      cmd.arguments[0],
+	}
+
+	folder, err := s.db.CreateFolder(context.Background(), parameter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Folder was created: %v\n", folder.Name)
+
+	return nil
+}
+
+func handlerFolders(s *state, _ command, user database.User) error {
+
+	folders, err := s.db.GetFoldersForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	if s.format == formatJSON {
+		names := make([]string, 0, len(folders))
+		for _, folder := range folders {
+			names = append(names, folder.Name)
+		}
+		return printJSON(names)
+	}
+
+	for _, folder := range folders {
+		fmt.Printf("%v\n", folder.Name)
+	}
+
+	return nil
+}
+
+func handlerRenameFolder(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) < 2 {
+		return fmt.Errorf("usage: renamefolder <folder> <new name>")
+	}
+
+	folder, err := getFolderFromName(s, user, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	parameter := database.RenameFolderParams{
+		ID:   folder.ID,
+		Name: cmd.arguments[1],
+	}
+
+	if err := s.db.RenameFolder(context.Background(), parameter); err != nil {
+		return err
+	}
+
+	fmt.Printf("Folder %v renamed to %v\n", folder.Name, cmd.arguments[1])
+
+	return nil
+}
+
+func handlerDeleteFolder(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no folder name given")
+	}
+
+	folder, err := getFolderFromName(s, user, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	// Follows in the folder are kept; the foreign key moves them back to
+	// the top level.
+	if err := s.db.DeleteFolder(context.Background(), folder.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Folder %v deleted\n", folder.Name)
+
+	return nil
+}
+
+// handlerMoveFeed moves a followed feed into a folder, or out of any folder
+// when no folder is given.
+func handlerMoveFeed(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no feed url given")
+	}
+
+	feed, err := s.db.GetFeed(context.Background(), cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	parameter := database.SetFeedFollowFolderParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	}
+
+	if len(cmd.arguments) > 1 {
+		folder, err := getFolderFromName(s, user, cmd.arguments[1])
+		if err != nil {
+			return err
+		}
+		parameter.FolderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	moved, err := s.db.SetFeedFollowFolder(context.Background(), parameter)
+	if err != nil {
+		return err
+	}
+
+	if moved == 0 {
+		return fmt.Errorf("you do not follow this feed")
+	}
+
+	if parameter.FolderID.Valid {
+		fmt.Printf("Feed %v moved to %v\n", feed.Name, cmd.arguments[1])
+	} else {
+		fmt.Printf("Feed %v removed from its folder\n", feed.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Omorfii/aggregator/internal/database"
+)
+
+func TestMoveFeed(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		arguments  string
+		follows    bool
+		wantErr    bool
+		wantFolder string
+	}{
+		{name: "into a folder", arguments: "https://example.com/a news", follows: true, wantFolder: "news"},
+		{name: "out of its folder", arguments: "https://example.com/a", follows: true},
+		{name: "unknown folder", arguments: "https://example.com/a later", follows: true, wantErr: true, wantFolder: "tech"},
+		{name: "another user's folder", arguments: "https://example.com/a private", follows: true, wantErr: true, wantFolder: "tech"},
+		{name: "feed not followed", arguments: "https://example.com/a news", wantErr: true},
+		{name: "unknown feed", arguments: "https://example.com/b news", follows: true, wantErr: true, wantFolder: "tech"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			alice := addUser(t, s, "alice", roleMember, true)
+			bob := addUser(t, s, "bob", roleMember, true)
+
+			captureOutput(t, func() {
+				for _, folder := range []string{"news", "tech"} {
+					if err := handlerAddFolder(s, command{name: "addfolder", arguments: []string{folder}}, alice); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := handlerAddFolder(s, command{name: "addfolder", arguments: []string{"private"}}, bob); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			feed := addFeed(t, s, bob, "a", "https://example.com/a")
+			if test.follows {
+				follow(t, s, alice, feed)
+				captureOutput(t, func() {
+					if err := handlerMoveFeed(s, command{name: "movefeed", arguments: []string{feed.Url, "tech"}}, alice); err != nil {
+						t.Fatal(err)
+					}
+				})
+			}
+
+			var err error
+			captureOutput(t, func() {
+				err = handlerMoveFeed(s, command{name: "movefeed", arguments: strings.Fields(test.arguments)}, alice)
+			})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("movefeed %v = %v, want error %v", test.arguments, err, test.wantErr)
+			}
+
+			follows, err := s.db.GetFeedFollowsForUser(context.Background(), alice.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !test.follows {
+				if len(follows) != 0 {
+					t.Errorf("movefeed made alice follow %d feeds", len(follows))
+				}
+				return
+			}
+
+			folder := ""
+			if follows[0].FolderID.Valid {
+				folders, err := s.db.GetFoldersForUser(context.Background(), alice.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, f := range folders {
+					if f.ID == follows[0].FolderID.UUID {
+						folder = f.Name
+					}
+				}
+			}
+			if folder != test.wantFolder {
+				t.Errorf("feed is in folder %q, want %q", folder, test.wantFolder)
+			}
+		})
+	}
+}
+
+func TestDeleteFolderKeepsFeeds(t *testing.T) {
+
+	s := newTestState(t)
+	alice := addUser(t, s, "alice", roleMember, true)
+	feed := addFeed(t, s, alice, "a", "https://example.com/a")
+	follow(t, s, alice, feed)
+
+	steps := []struct {
+		handler func(*state, command, database.User) error
+		cmd     command
+	}{
+		{handlerAddFolder, command{name: "addfolder", arguments: []string{"news"}}},
+		{handlerMoveFeed, command{name: "movefeed", arguments: []string{feed.Url, "news"}}},
+		{handlerRenameFolder, command{name: "renamefolder", arguments: []string{"news", "old news"}}},
+		{handlerDeleteFolder, command{name: "deletefolder", arguments: []string{"old news"}}},
+	}
+
+	captureOutput(t, func() {
+		for _, step := range steps {
+			if err := step.handler(s, step.cmd, alice); err != nil {
+				t.Fatalf("%v %v = %v", step.cmd.name, strings.Join(step.cmd.arguments, " "), err)
+			}
+		}
+	})
+
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(follows) != 1 || follows[0].FolderID.Valid {
+		t.Errorf("follows after deletefolder are %+v, want the feed at the top level", follows)
+	}
+
+	folders, err := s.db.GetFoldersForUser(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(folders) != 0 {
+		t.Errorf("folders left are %+v, want none", folders)
+	}
+}
@@ -0,0 +1,241 @@
+package backup
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// The rows of an archive are copies of the database models with their own
+// JSON names, so that the archive format only changes when Version does,
+// not whenever a column is added to the schema.
+
+type User struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"password_hash"`
+	Role         string    `json:"role"`
+}
+
+type Feed struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Name          string     `json:"name"`
+	Url           string     `json:"url"`
+	UserID        uuid.UUID  `json:"user_id"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	NextFetchAt   *time.Time `json:"next_fetch_at"`
+}
+
+type Folder struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+}
+
+type FeedFollow struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UserID    uuid.UUID  `json:"user_id"`
+	FeedID    uuid.UUID  `json:"feed_id"`
+	FolderID  *uuid.UUID `json:"folder_id"`
+	Title     *string    `json:"title"`
+}
+
+type Post struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Description *string    `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+}
+
+type PostState struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	PostID    uuid.UUID  `json:"post_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ReadAt    *time.Time `json:"read_at"`
+	SavedAt   *time.Time `json:"saved_at"`
+	HiddenAt  *time.Time `json:"hidden_at"`
+}
+
+type Tag struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+}
+
+type PostTag struct {
+	TagID     uuid.UUID `json:"tag_id"`
+	PostID    uuid.UUID `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FilterRule struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Field     string    `json:"field"`
+	Pattern   string    `json:"pattern"`
+	IsRegex   bool      `json:"is_regex"`
+	Action    string    `json:"action"`
+	Tag       *string   `json:"tag"`
+}
+
+// modelArchive holds the tables as database models. It is also the layout
+// of version 1 archives, which wrote the models as they were, with Go
+// field names and sql.Null* values as objects; archives from before
+// passwords and roles have neither.
+type modelArchive struct {
+	Version     int                   `json:"version"`
+	CreatedAt   time.Time             `json:"created_at"`
+	Users       []database.User       `json:"users"`
+	Feeds       []database.Feed       `json:"feeds"`
+	Folders     []database.Folder     `json:"folders"`
+	FeedFollows []database.FeedFollow `json:"feed_follows"`
+	Posts       []database.Post       `json:"posts"`
+	PostStates  []database.PostState  `json:"post_states"`
+	Tags        []database.Tag        `json:"tags"`
+	PostTags    []database.PostTag    `json:"post_tags"`
+	FilterRules []database.FilterRule `json:"filter_rules"`
+}
+
+// convert turns the models into an archive of the current version.
+func (m modelArchive) convert() Archive {
+
+	archive := Archive{
+		Version:   Version,
+		CreatedAt: m.CreatedAt,
+	}
+
+	for _, user := range m.Users {
+		archive.Users = append(archive.Users, User(user))
+	}
+	for _, feed := range m.Feeds {
+		archive.Feeds = append(archive.Feeds, Feed{
+			ID:            feed.ID,
+			CreatedAt:     feed.CreatedAt,
+			UpdatedAt:     feed.UpdatedAt,
+			Name:          feed.Name,
+			Url:           feed.Url,
+			UserID:        feed.UserID,
+			LastFetchedAt: timePointer(feed.LastFetchedAt),
+			NextFetchAt:   timePointer(feed.NextFetchAt),
+		})
+	}
+	for _, folder := range m.Folders {
+		archive.Folders = append(archive.Folders, Folder(folder))
+	}
+	for _, feedFollow := range m.FeedFollows {
+		archive.FeedFollows = append(archive.FeedFollows, FeedFollow{
+			ID:        feedFollow.ID,
+			CreatedAt: feedFollow.CreatedAt,
+			UpdatedAt: feedFollow.UpdatedAt,
+			UserID:    feedFollow.UserID,
+			FeedID:    feedFollow.FeedID,
+			FolderID:  idPointer(feedFollow.FolderID),
+			Title:     stringPointer(feedFollow.Title),
+		})
+	}
+	for _, post := range m.Posts {
+		archive.Posts = append(archive.Posts, Post{
+			ID:          post.ID,
+			CreatedAt:   post.CreatedAt,
+			UpdatedAt:   post.UpdatedAt,
+			Title:       post.Title,
+			Url:         post.Url,
+			Description: stringPointer(post.Description),
+			PublishedAt: timePointer(post.PublishedAt),
+			FeedID:      post.FeedID,
+		})
+	}
+	for _, postState := range m.PostStates {
+		archive.PostStates = append(archive.PostStates, PostState{
+			UserID:    postState.UserID,
+			PostID:    postState.PostID,
+			CreatedAt: postState.CreatedAt,
+			UpdatedAt: postState.UpdatedAt,
+			ReadAt:    timePointer(postState.ReadAt),
+			SavedAt:   timePointer(postState.SavedAt),
+			HiddenAt:  timePointer(postState.HiddenAt),
+		})
+	}
+	for _, tag := range m.Tags {
+		archive.Tags = append(archive.Tags, Tag(tag))
+	}
+	for _, postTag := range m.PostTags {
+		archive.PostTags = append(archive.PostTags, PostTag(postTag))
+	}
+	for _, rule := range m.FilterRules {
+		archive.FilterRules = append(archive.FilterRules, FilterRule{
+			ID:        rule.ID,
+			CreatedAt: rule.CreatedAt,
+			UpdatedAt: rule.UpdatedAt,
+			UserID:    rule.UserID,
+			Field:     rule.Field,
+			Pattern:   rule.Pattern,
+			IsRegex:   rule.IsRegex,
+			Action:    rule.Action,
+			Tag:       stringPointer(rule.Tag),
+		})
+	}
+
+	return archive
+}
+
+func timePointer(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func stringPointer(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func idPointer(value uuid.NullUUID) *uuid.UUID {
+	if !value.Valid {
+		return nil
+	}
+	return &value.UUID
+}
+
+func nullTime(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *value, Valid: true}
+}
+
+func nullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+func nullID(value *uuid.UUID) uuid.NullUUID {
+	if value == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *value, Valid: true}
+}
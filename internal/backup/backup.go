@@ -0,0 +1,122 @@
+// Package backup dumps gator's data into a gzip-compressed JSON archive.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+)
+
+// Version is the archive format version written by Dump. It is bumped
+// whenever the shape of Archive or of its rows changes.
+//
+// Version 2 gave every field a snake_case name and writes missing values
+// as null; version 1 wrote the database models as they were.
+const Version = 2
+
+type Archive struct {
+	Version     int          `json:"version"`
+	CreatedAt   time.Time    `json:"created_at"`
+	Users       []User       `json:"users"`
+	Feeds       []Feed       `json:"feeds"`
+	Folders     []Folder     `json:"folders"`
+	FeedFollows []FeedFollow `json:"feed_follows"`
+	Posts       []Post       `json:"posts"`
+	PostStates  []PostState  `json:"post_states"`
+	Tags        []Tag        `json:"tags"`
+	PostTags    []PostTag    `json:"post_tags"`
+	FilterRules []FilterRule `json:"filter_rules"`
+}
+
+// Dump reads every table into an Archive.
+func Dump(ctx context.Context, q database.Querier) (Archive, error) {
+
+	archive := modelArchive{
+		CreatedAt: time.Now(),
+	}
+
+	var err error
+
+	if archive.Users, err = q.GetUsers(ctx); err != nil {
+		return Archive{}, err
+	}
+	if archive.Feeds, err = q.GetFeeds(ctx); err != nil {
+		return Archive{}, err
+	}
+	if archive.Folders, err = q.GetFolders(ctx); err != nil {
+		return Archive{}, err
+	}
+	if archive.FeedFollows, err = q.GetFeedFollows(ctx); err != nil {
+		return Archive{}, err
+	}
+	if archive.Posts, err = q.GetPosts(ctx); err != nil {
+		return Archive{}, err
+	}
+	if archive.PostStates, err = q.GetPostStates(ctx); err != nil {
+		return Archive{}, err
+	}
+	if archive.Tags, err = q.GetTags(ctx); err != nil {
+		return Archive{}, err
+	}
+	if archive.PostTags, err = q.GetPostTags(ctx); err != nil {
+		return Archive{}, err
+	}
+	if archive.FilterRules, err = q.GetFilterRules(ctx); err != nil {
+		return Archive{}, err
+	}
+
+	return archive.convert(), nil
+}
+
+// Write encodes archive as gzip-compressed JSON.
+func Write(w io.Writer, archive Archive) error {
+
+	gz := gzip.NewWriter(w)
+
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		gz.Close()
+		return err
+	}
+
+	return gz.Close()
+}
+
+// WriteFile writes archive to path, creating its directory if needed. The
+// file is only readable by its owner since it holds every user's data.
+func WriteFile(path string, archive Archive) error {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(file, archive); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// DefaultPath returns a timestamped archive path under ~/.gator/backups.
+func DefaultPath(label string) (string, error) {
+
+	homePath, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := "gator-" + label + "-" + time.Now().Format("20060102-150405.000000") + ".json.gz"
+
+	return filepath.Join(homePath, ".gator", "backups", name), nil
+}
@@ -0,0 +1,254 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/Omorfii/aggregator/internal/storage/storagetest"
+	"github.com/google/uuid"
+)
+
+// seed fills q with one row of every table, with nullable columns both set
+// and unset.
+func seed(t *testing.T, q database.Querier) {
+
+	t.Helper()
+
+	ctx := context.Background()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	valid := sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+
+	user := database.RestoreUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "alice", PasswordHash: "$2a$10$hash", Role: "admin"}
+	feed := database.RestoreFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "feed", Url: "https://example.com/feed", UserID: user.ID, LastFetchedAt: valid}
+	folder := database.RestoreFolderParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, Name: "news"}
+	follow := database.RestoreFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID, FolderID: uuid.NullUUID{UUID: folder.ID, Valid: true}, Title: sql.NullString{String: "My feed", Valid: true}}
+	post := database.RestorePostParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "post", Url: "https://example.com/post", PublishedAt: valid, FeedID: feed.ID}
+	state := database.RestorePostStateParams{UserID: user.ID, PostID: post.ID, CreatedAt: now, UpdatedAt: now, ReadAt: valid}
+	tag := database.RestoreTagParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, Name: "later"}
+	postTag := database.RestorePostTagParams{TagID: tag.ID, PostID: post.ID, CreatedAt: now}
+	rule := database.RestoreFilterRuleParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, Field: "title", Pattern: "ad", Action: "tag", Tag: sql.NullString{String: "later", Valid: true}}
+
+	for _, restore := range []func() (int64, error){
+		func() (int64, error) { return q.RestoreUser(ctx, user) },
+		func() (int64, error) { return q.RestoreFeed(ctx, feed) },
+		func() (int64, error) { return q.RestoreFolder(ctx, folder) },
+		func() (int64, error) { return q.RestoreFeedFollow(ctx, follow) },
+		func() (int64, error) { return q.RestorePost(ctx, post) },
+		func() (int64, error) { return q.RestorePostState(ctx, state) },
+		func() (int64, error) { return q.RestoreTag(ctx, tag) },
+		func() (int64, error) { return q.RestorePostTag(ctx, postTag) },
+		func() (int64, error) { return q.RestoreFilterRule(ctx, rule) },
+	} {
+		if _, err := restore(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// roundTrip writes archive and reads it back.
+func roundTrip(t *testing.T, archive Archive) Archive {
+
+	t.Helper()
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, archive); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := Read(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return read
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	ctx := context.Background()
+
+	source := storagetest.Open(t)
+	seed(t, source)
+
+	archive, err := Dump(ctx, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := roundTrip(t, archive)
+	if !reflect.DeepEqual(read.Users, archive.Users) || !reflect.DeepEqual(read.FeedFollows, archive.FeedFollows) {
+		t.Errorf("archive changed when written and read back:\n%+v\n%+v", archive, read)
+	}
+
+	target := storagetest.Open(t)
+
+	stats, err := Restore(ctx, target, read, Fail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"users", "feeds", "folders", "feed_follows", "posts", "post_states", "tags", "post_tags", "filter_rules"} {
+		if stats.Restored[table] != 1 {
+			t.Errorf("%v: %d rows restored, want 1", table, stats.Restored[table])
+		}
+	}
+
+	restored, err := Dump(ctx, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored.CreatedAt = archive.CreatedAt
+
+	want, _ := json.Marshal(archive)
+	got, _ := json.Marshal(restored)
+	if !bytes.Equal(got, want) {
+		t.Errorf("restored database differs from the backup:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestRoundTripWithoutPassword(t *testing.T) {
+
+	// Users from before passwords have none until they use a reset token,
+	// and backups taken meanwhile must still restore.
+	ctx := context.Background()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	source := storagetest.Open(t)
+	if _, err := source.RestoreUser(ctx, database.RestoreUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "alice", Role: "admin"}); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := Dump(ctx, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := storagetest.Open(t)
+	if _, err := Restore(ctx, target, roundTrip(t, archive), Fail); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := target.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.PasswordHash != "" || user.Role != "admin" {
+		t.Errorf("restored password hash %q and role %q, want none and admin", user.PasswordHash, user.Role)
+	}
+}
+
+func TestRestoreConflicts(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		onConflict OnConflict
+		wantErr    bool
+	}{
+		{name: "skip", onConflict: Skip},
+		{name: "fail", onConflict: Fail, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			ctx := context.Background()
+			store := storagetest.Open(t)
+			seed(t, store)
+
+			archive, err := Dump(ctx, store)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stats, err := Restore(ctx, store, archive, test.onConflict)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Restore = %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr && (stats.Skipped["users"] != 1 || stats.Restored["posts"] != 0) {
+				t.Errorf("restoring into the same database: %+v, want every row skipped", stats)
+			}
+		})
+	}
+}
+
+func TestReadVersion1(t *testing.T) {
+
+	// The layout written before archives had their own rows: Go field
+	// names, and no password or role before those existed.
+	const version1 = `{"version":1,"created_at":"2026-01-02T03:04:05Z",
+		"users":[{"ID":"11111111-1111-1111-1111-111111111111","CreatedAt":"2026-01-01T00:00:00Z","UpdatedAt":"2026-01-01T00:00:00Z","Name":"alice"%v}],
+		"feed_follows":[{"ID":"22222222-2222-2222-2222-222222222222","UserID":"11111111-1111-1111-1111-111111111111","FeedID":"33333333-3333-3333-3333-333333333333","FolderID":null,"Title":{"String":"Mine","Valid":true}}]}`
+
+	tests := []struct {
+		name         string
+		user         string
+		wantPassword string
+		wantRole     string
+	}{
+		{name: "with password and role", user: `,"PasswordHash":"$2a$10$hash","Role":"admin"`, wantPassword: "$2a$10$hash", wantRole: "admin"},
+		{name: "without role", user: `,"PasswordHash":"$2a$10$hash"`, wantPassword: "$2a$10$hash", wantRole: defaultRole},
+		{name: "without password", user: ``, wantRole: defaultRole},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			var buffer bytes.Buffer
+			gz := gzip.NewWriter(&buffer)
+			gz.Write([]byte(fmt.Sprintf(version1, test.user)))
+			gz.Close()
+
+			archive, err := Read(&buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if archive.Version != Version {
+				t.Errorf("read archive has version %d, want %d", archive.Version, Version)
+			}
+			if title := archive.FeedFollows[0].Title; title == nil || *title != "Mine" || archive.FeedFollows[0].FolderID != nil {
+				t.Errorf("feed follow read as %+v", archive.FeedFollows[0])
+			}
+
+			ctx := context.Background()
+			store := storagetest.Open(t)
+
+			archive.FeedFollows = nil
+			if _, err := Restore(ctx, store, archive, Fail); err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := store.GetUser(ctx, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.PasswordHash != test.wantPassword || user.Role != test.wantRole {
+				t.Errorf("restored password hash %q and role %q, want %q and %q", user.PasswordHash, user.Role, test.wantPassword, test.wantRole)
+			}
+		})
+	}
+}
+
+func TestReadRejectsUnknownVersion(t *testing.T) {
+
+	for _, version := range []int{0, Version + 1} {
+
+		var buffer bytes.Buffer
+		gz := gzip.NewWriter(&buffer)
+		json.NewEncoder(gz).Encode(map[string]int{"version": version})
+		gz.Close()
+
+		if _, err := Read(&buffer); err == nil {
+			t.Errorf("reading an archive of version %d succeeded, want an error", version)
+		}
+	}
+}
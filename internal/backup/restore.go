@@ -0,0 +1,306 @@
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// OnConflict decides what Restore does with a row that already exists,
+// either with the same id or the same unique key.
+type OnConflict string
+
+const (
+	// Skip keeps the existing row. References to a skipped row that was
+	// matched by its unique key are pointed at the existing row's id.
+	Skip OnConflict = "skip"
+	// Fail aborts the restore on the first conflicting row.
+	Fail OnConflict = "fail"
+)
+
+// Stats counts restored and skipped rows per table.
+type Stats struct {
+	Restored map[string]int
+	Skipped  map[string]int
+}
+
+// Read decodes an archive written by Write.
+func Read(r io.Reader) (Archive, error) {
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Archive{}, err
+	}
+	defer gz.Close()
+
+	content, err := io.ReadAll(gz)
+	if err != nil {
+		return Archive{}, err
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(content, &header); err != nil {
+		return Archive{}, err
+	}
+
+	switch header.Version {
+	case 1:
+		var archive modelArchive
+		if err := json.Unmarshal(content, &archive); err != nil {
+			return Archive{}, err
+		}
+		return archive.convert(), nil
+
+	case Version:
+		var archive Archive
+		if err := json.Unmarshal(content, &archive); err != nil {
+			return Archive{}, err
+		}
+		return archive, nil
+	}
+
+	return Archive{}, fmt.Errorf("unsupported archive version %d, this gator reads up to version %d", header.Version, Version)
+}
+
+func ReadFile(path string) (Archive, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Archive{}, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
+
+// defaultRole is given to users archived before roles existed, like the
+// column default of the users table.
+const defaultRole = "member"
+
+type restorer struct {
+	onConflict OnConflict
+	ids        map[uuid.UUID]uuid.UUID
+	stats      Stats
+}
+
+// id returns the id a restored row should reference in place of the
+// archived one.
+func (r *restorer) id(archived uuid.UUID) uuid.UUID {
+	if id, exists := r.ids[archived]; exists {
+		return id
+	}
+	return archived
+}
+
+func (r *restorer) nullID(archived uuid.NullUUID) uuid.NullUUID {
+	if !archived.Valid {
+		return archived
+	}
+	return uuid.NullUUID{UUID: r.id(archived.UUID), Valid: true}
+}
+
+// record counts the result of one insert. When the row was not inserted
+// and lookup finds the existing row by its unique key, later references to
+// archived are remapped to it.
+func (r *restorer) record(table string, archived uuid.UUID, inserted int64, err error, lookup func() (uuid.UUID, error)) error {
+
+	if err != nil {
+		return fmt.Errorf("restoring %v %v: %w", table, archived, err)
+	}
+
+	if inserted > 0 {
+		r.stats.Restored[table]++
+		return nil
+	}
+
+	if r.onConflict == Fail {
+		return fmt.Errorf("restoring %v %v: row already exists", table, archived)
+	}
+
+	r.stats.Skipped[table]++
+
+	if lookup == nil {
+		return nil
+	}
+
+	existing, err := lookup()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	r.ids[archived] = existing
+
+	return nil
+}
+
+// Restore inserts every row of archive into q, keeping the archived ids.
+// Tables are restored parents first so that references can be remapped.
+// Users without a password hash are restored without one and get a
+// password through a reset token, like any account from before passwords.
+// Users without a role become members.
+func Restore(ctx context.Context, q database.Querier, archive Archive, onConflict OnConflict) (Stats, error) {
+
+	r := &restorer{
+		onConflict: onConflict,
+		ids:        make(map[uuid.UUID]uuid.UUID),
+		stats: Stats{
+			Restored: make(map[string]int),
+			Skipped:  make(map[string]int),
+		},
+	}
+
+	for _, user := range archive.Users {
+		if user.Role == "" {
+			user.Role = defaultRole
+		}
+		inserted, err := q.RestoreUser(ctx, database.RestoreUserParams(user))
+		err = r.record("users", user.ID, inserted, err, func() (uuid.UUID, error) {
+			existing, err := q.GetUser(ctx, user.Name)
+			return existing.ID, err
+		})
+		if err != nil {
+			return r.stats, err
+		}
+	}
+
+	for _, feed := range archive.Feeds {
+		feed.UserID = r.id(feed.UserID)
+		inserted, err := q.RestoreFeed(ctx, database.RestoreFeedParams{
+			ID:            feed.ID,
+			CreatedAt:     feed.CreatedAt,
+			UpdatedAt:     feed.UpdatedAt,
+			Name:          feed.Name,
+			Url:           feed.Url,
+			UserID:        feed.UserID,
+			LastFetchedAt: nullTime(feed.LastFetchedAt),
+			NextFetchAt:   nullTime(feed.NextFetchAt),
+		})
+		err = r.record("feeds", feed.ID, inserted, err, func() (uuid.UUID, error) {
+			existing, err := q.GetFeed(ctx, feed.Url)
+			return existing.ID, err
+		})
+		if err != nil {
+			return r.stats, err
+		}
+	}
+
+	for _, folder := range archive.Folders {
+		folder.UserID = r.id(folder.UserID)
+		inserted, err := q.RestoreFolder(ctx, database.RestoreFolderParams(folder))
+		err = r.record("folders", folder.ID, inserted, err, func() (uuid.UUID, error) {
+			existing, err := q.GetFolder(ctx, database.GetFolderParams{UserID: folder.UserID, Name: folder.Name})
+			return existing.ID, err
+		})
+		if err != nil {
+			return r.stats, err
+		}
+	}
+
+	for _, feedFollow := range archive.FeedFollows {
+		feedFollow.UserID = r.id(feedFollow.UserID)
+		feedFollow.FeedID = r.id(feedFollow.FeedID)
+		inserted, err := q.RestoreFeedFollow(ctx, database.RestoreFeedFollowParams{
+			ID:        feedFollow.ID,
+			CreatedAt: feedFollow.CreatedAt,
+			UpdatedAt: feedFollow.UpdatedAt,
+			UserID:    feedFollow.UserID,
+			FeedID:    feedFollow.FeedID,
+			FolderID:  r.nullID(nullID(feedFollow.FolderID)),
+			Title:     nullString(feedFollow.Title),
+		})
+		if err := r.record("feed_follows", feedFollow.ID, inserted, err, nil); err != nil {
+			return r.stats, err
+		}
+	}
+
+	for _, post := range archive.Posts {
+		post.FeedID = r.id(post.FeedID)
+		inserted, err := q.RestorePost(ctx, database.RestorePostParams{
+			ID:          post.ID,
+			CreatedAt:   post.CreatedAt,
+			UpdatedAt:   post.UpdatedAt,
+			Title:       post.Title,
+			Url:         post.Url,
+			Description: nullString(post.Description),
+			PublishedAt: nullTime(post.PublishedAt),
+			FeedID:      post.FeedID,
+		})
+		err = r.record("posts", post.ID, inserted, err, func() (uuid.UUID, error) {
+			existing, err := q.GetPostByURL(ctx, post.Url)
+			return existing.ID, err
+		})
+		if err != nil {
+			return r.stats, err
+		}
+	}
+
+	for _, postState := range archive.PostStates {
+		postState.UserID = r.id(postState.UserID)
+		postState.PostID = r.id(postState.PostID)
+		inserted, err := q.RestorePostState(ctx, database.RestorePostStateParams{
+			UserID:    postState.UserID,
+			PostID:    postState.PostID,
+			CreatedAt: postState.CreatedAt,
+			UpdatedAt: postState.UpdatedAt,
+			ReadAt:    nullTime(postState.ReadAt),
+			SavedAt:   nullTime(postState.SavedAt),
+			HiddenAt:  nullTime(postState.HiddenAt),
+		})
+		if err := r.record("post_states", postState.PostID, inserted, err, nil); err != nil {
+			return r.stats, err
+		}
+	}
+
+	for _, tag := range archive.Tags {
+		tag.UserID = r.id(tag.UserID)
+		inserted, err := q.RestoreTag(ctx, database.RestoreTagParams(tag))
+		err = r.record("tags", tag.ID, inserted, err, func() (uuid.UUID, error) {
+			existing, err := q.GetTag(ctx, database.GetTagParams{UserID: tag.UserID, Name: tag.Name})
+			return existing.ID, err
+		})
+		if err != nil {
+			return r.stats, err
+		}
+	}
+
+	for _, postTag := range archive.PostTags {
+		postTag.TagID = r.id(postTag.TagID)
+		postTag.PostID = r.id(postTag.PostID)
+		inserted, err := q.RestorePostTag(ctx, database.RestorePostTagParams(postTag))
+		if err := r.record("post_tags", postTag.PostID, inserted, err, nil); err != nil {
+			return r.stats, err
+		}
+	}
+
+	for _, rule := range archive.FilterRules {
+		rule.UserID = r.id(rule.UserID)
+		inserted, err := q.RestoreFilterRule(ctx, database.RestoreFilterRuleParams{
+			ID:        rule.ID,
+			CreatedAt: rule.CreatedAt,
+			UpdatedAt: rule.UpdatedAt,
+			UserID:    rule.UserID,
+			Field:     rule.Field,
+			Pattern:   rule.Pattern,
+			IsRegex:   rule.IsRegex,
+			Action:    rule.Action,
+			Tag:       nullString(rule.Tag),
+		})
+		if err := r.record("filter_rules", rule.ID, inserted, err, nil); err != nil {
+			return r.stats, err
+		}
+	}
+
+	return r.stats, nil
+}
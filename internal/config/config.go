@@ -2,68 +2,386 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 )
 
 type Config struct {
-	Url         string `json:"db_url"`
-	CurrentUser string `json:"current_user_name"`
+	Url          string          `json:"db_url"`
+	SessionToken string          `json:"session_token,omitempty"`
+	Retention    RetentionConfig `json:"retention"`
+	Fetch        FetchConfig     `json:"fetch"`
+
+	profile string
 }
 
-const configFileName = ".gatorconfig.json"
+// FetchConfig controls how agg downloads feeds. Timeouts are
+// time.ParseDuration strings; zero values use the defaults of the fetch
+// package. Allow and Deny hold address ranges as parsed by
+// ParseAddressRange.
+type FetchConfig struct {
+	ConnectTimeout  string   `json:"connect_timeout,omitempty"`
+	ReadTimeout     string   `json:"read_timeout,omitempty"`
+	MaxBodySize     int64    `json:"max_body_size,omitempty"`
+	MaxRedirects    *int     `json:"max_redirects,omitempty"`
+	CABundle        string   `json:"ca_bundle,omitempty"`
+	Proxy           string   `json:"proxy,omitempty"`
+	Allow           []string `json:"allow,omitempty"`
+	Deny            []string `json:"deny,omitempty"`
+	HostInterval    string   `json:"host_interval,omitempty"`
+	HostConcurrency int      `json:"host_concurrency,omitempty"`
+	UserAgent       string   `json:"user_agent,omitempty"`
+	ContactURL      string   `json:"contact_url,omitempty"`
+}
 
-func getConfigFilePath() (string, error) {
+// RetentionPolicy limits how many posts are kept for a feed. MaxAge is a
+// time.ParseDuration string such as "720h"; zero values mean no limit.
+type RetentionPolicy struct {
+	MaxAge   string `json:"max_age,omitempty"`
+	MaxPosts int32  `json:"max_posts,omitempty"`
+}
 
-	homePath, err := os.UserHomeDir()
+// RetentionConfig is the global retention policy plus per-feed overrides
+// keyed by feed url.
+type RetentionConfig struct {
+	RetentionPolicy
+	UnreadWindow string                     `json:"unread_window,omitempty"`
+	PruneOnAgg   bool                       `json:"prune_on_agg,omitempty"`
+	Feeds        map[string]RetentionPolicy `json:"feeds,omitempty"`
+}
+
+// PolicyFor returns the global policy with any fields set for feedURL
+// taking precedence.
+func (r RetentionConfig) PolicyFor(feedURL string) RetentionPolicy {
+
+	policy := r.RetentionPolicy
+
+	override, exists := r.Feeds[feedURL]
+	if !exists {
+		return policy
+	}
+
+	if override.MaxAge != "" {
+		policy.MaxAge = override.MaxAge
+	}
+	if override.MaxPosts != 0 {
+		policy.MaxPosts = override.MaxPosts
+	}
+
+	return policy
+}
+
+// legacyConfigFileName is the file in the home directory used before the
+// config moved under the XDG config directory. It is still read when it
+// exists and the new file does not.
+const legacyConfigFileName = ".gatorconfig.json"
+
+// DefaultDBURL is the database used by a freshly created config.
+const DefaultDBURL = "sqlite://~/.gator/gator.db"
+
+// DefaultProfile is the profile used when none is selected, and the name
+// given to the settings of a legacy single-profile config file.
+const DefaultProfile = "default"
+
+// file is the on-disk layout of the config file.
+type file struct {
+	CurrentProfile string            `json:"current_profile"`
+	Profiles       map[string]Config `json:"profiles"`
+}
+
+var configPath string
+
+// SetPath makes the package use the config file at path instead of looking
+// one up. An empty path restores the lookup.
+func SetPath(path string) {
+	configPath = path
+}
+
+// Path returns the config file in use: the one given to SetPath, then
+// $GATOR_CONFIG, then $XDG_CONFIG_HOME/gator/config.json, falling back to
+// ~/.gatorconfig.json if only that one exists.
+func Path() (string, error) {
+
+	if configPath != "" {
+		return configPath, nil
+	}
+
+	if envPath := os.Getenv("GATOR_CONFIG"); envPath != "" {
+		return envPath, nil
+	}
+
+	configDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
 	}
 
-	truePath := filepath.Join(homePath, configFileName)
+	truePath := filepath.Join(configDir, "gator", "config.json")
+
+	if _, err := os.Stat(truePath); err == nil {
+		return truePath, nil
+	}
+
+	homePath, err := os.UserHomeDir()
+	if err != nil {
+		return truePath, nil
+	}
+
+	legacyPath := filepath.Join(homePath, legacyConfigFileName)
+	if _, err := os.Stat(legacyPath); err == nil {
+		return legacyPath, nil
+	}
 
 	return truePath, nil
 }
 
-func write(cfg Config) error {
+func defaultFile() file {
+	return file{
+		CurrentProfile: DefaultProfile,
+		Profiles: map[string]Config{
+			DefaultProfile: {Url: DefaultDBURL},
+		},
+	}
+}
+
+// readFile reads and parses the config file. It warns on stderr when the
+// file can be read by other users, since db_url may hold a password.
+func readFile() (file, error) {
 
-	jsonData, err := json.Marshal(cfg)
+	filepath, err := Path()
 	if err != nil {
+		return file{}, err
+	}
+
+	jsonData, err := os.ReadFile(filepath)
+	if err != nil {
+		return file{}, err
+	}
+
+	if info, err := os.Stat(filepath); err == nil && info.Mode().Perm()&0004 != 0 {
+		slog.Warn("config file is readable by every user, run chmod 600 on it", "path", filepath)
+	}
+
+	// A legacy file has the settings of a single profile at the top level.
+	var contents struct {
+		file
+		Config
+	}
+	if err := json.Unmarshal(jsonData, &contents); err != nil {
+		return file{}, fmt.Errorf("couldn't parse %v: %w", filepath, err)
+	}
+
+	cfgFile := contents.file
+
+	if len(cfgFile.Profiles) == 0 {
+		cfgFile.Profiles = map[string]Config{DefaultProfile: contents.Config}
+	}
+
+	if cfgFile.CurrentProfile == "" {
+		cfgFile.CurrentProfile = DefaultProfile
+	}
+
+	return cfgFile, nil
+}
+
+// loadFile reads the config file, creating a default one if it does not
+// exist yet.
+func loadFile() (file, error) {
+
+	cfgFile, err := readFile()
+	if errors.Is(err, os.ErrNotExist) {
+		err = updateFile(func(created *file) error {
+			cfgFile = *created
+			return nil
+		})
+		if err != nil {
+			return file{}, fmt.Errorf("couldn't create default config: %w", err)
+		}
+		return cfgFile, nil
+	}
+
+	return cfgFile, err
+}
+
+// updateFile applies fn to the config file and saves the result, holding
+// the config lock so that concurrent gator processes don't overwrite each
+// other's changes.
+func updateFile(fn func(*file) error) error {
+
+	filePath, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
 		return err
 	}
 
-	filepath, err := getConfigFilePath()
+	unlock, err := lock(filePath)
 	if err != nil {
 		return err
 	}
+	defer unlock()
+
+	cfgFile, err := readFile()
+	if errors.Is(err, os.ErrNotExist) {
+		cfgFile = defaultFile()
+	} else if err != nil {
+		return err
+	}
 
-	return os.WriteFile(filepath, jsonData, 0666)
+	if err := fn(&cfgFile); err != nil {
+		return err
+	}
 
+	return writeFile(filePath, cfgFile)
 }
 
-func Read() (Config, error) {
+// writeFile replaces the config file atomically: the new contents go to a
+// temporary file in the same directory which is then renamed over it, so an
+// interrupted write never leaves a truncated config behind.
+func writeFile(filePath string, cfgFile file) error {
 
-	filepath, err := getConfigFilePath()
+	jsonData, err := json.MarshalIndent(cfgFile, "", "  ")
 	if err != nil {
-		return Config{}, err
+		return err
 	}
 
-	jsonData, err := os.ReadFile(filepath)
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), ".gator-config-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := tmpFile.Chmod(0600); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if _, err := tmpFile.Write(jsonData); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), filePath)
+
+}
+
+// Read returns the settings of the named profile. An empty name selects
+// the GATOR_PROFILE environment variable, then the file's current profile.
+//
+// Settings are layered: built-in defaults, then the config file, then
+// GATOR_* environment variables (see EnvVar), then overrides, which maps
+// keys to values given on the command line.
+func Read(profile string, overrides map[string]string) (Config, error) {
+
+	cfgFile, err := loadFile()
 	if err != nil {
 		return Config{}, err
 	}
 
-	var config Config
-	if err := json.Unmarshal(jsonData, &config); err != nil {
+	return fromFile(cfgFile, profile, overrides)
+}
+
+// ReadExisting is Read for callers that must not leave files behind, like
+// shell completion: a missing config file gives the defaults instead of
+// being created.
+func ReadExisting(profile string, overrides map[string]string) (Config, error) {
+
+	cfgFile, err := readFile()
+	if errors.Is(err, os.ErrNotExist) {
+		cfgFile = defaultFile()
+	} else if err != nil {
 		return Config{}, err
 	}
 
+	return fromFile(cfgFile, profile, overrides)
+}
+
+// fromFile picks the profile from cfgFile and layers the environment and
+// overrides on top of it.
+func fromFile(cfgFile file, profile string, overrides map[string]string) (Config, error) {
+
+	if profile == "" {
+		profile = os.Getenv("GATOR_PROFILE")
+	}
+
+	if profile == "" {
+		profile = cfgFile.CurrentProfile
+	}
+
+	config, exists := cfgFile.Profiles[profile]
+	if !exists {
+		return Config{}, fmt.Errorf("profile %v does not exist", profile)
+	}
+
+	if config.Url == "" {
+		config.Url = DefaultDBURL
+	}
+
+	for _, key := range Keys {
+		value, exists := os.LookupEnv(EnvVar(key))
+		if !exists {
+			continue
+		}
+		if err := config.Set(key, value); err != nil {
+			return Config{}, fmt.Errorf("%v: %w", EnvVar(key), err)
+		}
+	}
+
+	for key, value := range overrides {
+		if err := config.Set(key, value); err != nil {
+			return Config{}, err
+		}
+	}
+
+	config.profile = profile
+
 	return config, nil
 }
 
-func (c *Config) SetUser(username string) error {
+// Profile returns the name of the profile c was read from.
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// SetSession stores the session token of the logged in user in c's
+// profile; an empty token logs out. Only that setting is written, so values
+// from the environment or the command line do not end up in the file.
+func (c *Config) SetSession(token string) error {
+
+	c.SessionToken = token
+
+	return Save(c.profile, "session_token", token)
+}
+
+// Save sets key to value in the named profile of the config file.
+func Save(profile string, key string, value string) error {
+
+	return updateFile(func(cfgFile *file) error {
+
+		config, exists := cfgFile.Profiles[profile]
+		if !exists {
+			return fmt.Errorf("profile %v does not exist", profile)
+		}
+
+		if err := config.Set(key, value); err != nil {
+			return err
+		}
 
-	c.CurrentUser = username
+		cfgFile.Profiles[profile] = config
 
-	return write(*c)
+		return nil
+	})
 }
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyFor(t *testing.T) {
+
+	retention := RetentionConfig{
+		RetentionPolicy: RetentionPolicy{MaxAge: "720h", MaxPosts: 100},
+		Feeds: map[string]RetentionPolicy{
+			"https://example.com/busy":  {MaxPosts: 20},
+			"https://example.com/quiet": {MaxAge: "8760h"},
+		},
+	}
+
+	tests := []struct {
+		feedURL string
+		want    RetentionPolicy
+	}{
+		{feedURL: "https://example.com/other", want: RetentionPolicy{MaxAge: "720h", MaxPosts: 100}},
+		{feedURL: "https://example.com/busy", want: RetentionPolicy{MaxAge: "720h", MaxPosts: 20}},
+		{feedURL: "https://example.com/quiet", want: RetentionPolicy{MaxAge: "8760h", MaxPosts: 100}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.feedURL, func(t *testing.T) {
+			if got := retention.PolicyFor(test.feedURL); got != test.want {
+				t.Errorf("PolicyFor(%v) = %+v, want %+v", test.feedURL, got, test.want)
+			}
+		})
+	}
+}
+
+// useConfigFile points the package at a config file with contents in a
+// temporary directory, and clears the environment variables Read looks at.
+func useConfigFile(t *testing.T, contents string) {
+
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if contents != "" {
+		if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetPath(path)
+	t.Cleanup(func() { SetPath("") })
+
+	t.Setenv("GATOR_PROFILE", "")
+	os.Unsetenv("GATOR_PROFILE")
+	for _, key := range Keys {
+		t.Setenv(EnvVar(key), "")
+		os.Unsetenv(EnvVar(key))
+	}
+}
+
+const layeredFile = `{
+  "current_profile": "home",
+  "profiles": {
+    "home": {
+      "db_url": "postgres://home",
+      "retention": {"max_age": "720h", "max_posts": 50},
+      "fetch": {"read_timeout": "20s"}
+    },
+    "work": {
+      "retention": {"max_posts": 10}
+    }
+  }
+}`
+
+func TestReadLayers(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		file      string
+		profile   string
+		env       map[string]string
+		overrides map[string]string
+		want      map[string]string
+	}{
+		{
+			name: "defaults without a file",
+			want: map[string]string{"db_url": DefaultDBURL, "retention.max_posts": "0", "fetch.read_timeout": ""},
+		},
+		{
+			name: "file",
+			file: layeredFile,
+			want: map[string]string{"db_url": "postgres://home", "retention.max_age": "720h", "retention.max_posts": "50", "fetch.read_timeout": "20s"},
+		},
+		{
+			name: "legacy file",
+			file: `{"db_url": "postgres://legacy", "current_user_name": "alice"}`,
+			want: map[string]string{"db_url": "postgres://legacy"},
+		},
+		{
+			name:    "profile argument",
+			file:    layeredFile,
+			profile: "work",
+			want:    map[string]string{"db_url": DefaultDBURL, "retention.max_posts": "10", "retention.max_age": ""},
+		},
+		{
+			name: "profile from the environment",
+			file: layeredFile,
+			env:  map[string]string{"GATOR_PROFILE": "work"},
+			want: map[string]string{"retention.max_posts": "10"},
+		},
+		{
+			name: "environment over file",
+			file: layeredFile,
+			env:  map[string]string{"GATOR_RETENTION_MAX_POSTS": "5", "GATOR_FETCH_ALLOW": "10.0.0.0/8, 127.0.0.1"},
+			want: map[string]string{"db_url": "postgres://home", "retention.max_posts": "5", "fetch.allow": "10.0.0.0/8,127.0.0.1"},
+		},
+		{
+			name:      "overrides over environment",
+			file:      layeredFile,
+			env:       map[string]string{"GATOR_DB_URL": "postgres://env"},
+			overrides: map[string]string{"db_url": "postgres://flag"},
+			want:      map[string]string{"db_url": "postgres://flag", "retention.max_age": "720h"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			useConfigFile(t, test.file)
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Read(test.profile, test.overrides)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for key, want := range test.want {
+				got, err := cfg.Get(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("%v = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		profile   string
+		env       map[string]string
+		overrides map[string]string
+	}{
+		{name: "unknown profile", profile: "nope"},
+		{name: "invalid environment value", env: map[string]string{"GATOR_RETENTION_MAX_AGE": "forever"}},
+		{name: "invalid override", overrides: map[string]string{"retention.max_posts": "-1"}},
+		{name: "unknown override", overrides: map[string]string{"no_such_key": "1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			useConfigFile(t, layeredFile)
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			if _, err := Read(test.profile, test.overrides); err == nil {
+				t.Error("Read succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestSaveKeepsEnvironmentOutOfFile(t *testing.T) {
+
+	useConfigFile(t, layeredFile)
+	t.Setenv("GATOR_DB_URL", "postgres://env")
+
+	cfg, err := Read("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.SetSession("token"); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Unsetenv("GATOR_DB_URL")
+
+	saved, err := Read("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if saved.Url != "postgres://home" || saved.SessionToken != "token" {
+		t.Errorf("saved db_url %q and session_token %q, want postgres://home and token", saved.Url, saved.SessionToken)
+	}
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys lists the settings that can be read and changed by name, with
+// config get/set, GATOR_* environment variables and command line flags.
+// Per-feed retention overrides are only set in the file.
+var Keys = []string{
+	"db_url",
+	"session_token",
+	"retention.max_age",
+	"retention.max_posts",
+	"retention.unread_window",
+	"retention.prune_on_agg",
+	"fetch.connect_timeout",
+	"fetch.read_timeout",
+	"fetch.max_body_size",
+	"fetch.max_redirects",
+	"fetch.ca_bundle",
+	"fetch.proxy",
+	"fetch.allow",
+	"fetch.deny",
+	"fetch.host_interval",
+	"fetch.host_concurrency",
+	"fetch.user_agent",
+	"fetch.contact_url",
+}
+
+// EnvVar returns the environment variable overriding key, e.g.
+// GATOR_RETENTION_MAX_AGE for retention.max_age.
+func EnvVar(key string) string {
+	return "GATOR_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Get returns the value of key formatted the way Set accepts it.
+func (c *Config) Get(key string) (string, error) {
+
+	switch key {
+	case "db_url":
+		return c.Url, nil
+	case "session_token":
+		return c.SessionToken, nil
+	case "retention.max_age":
+		return c.Retention.MaxAge, nil
+	case "retention.max_posts":
+		return strconv.Itoa(int(c.Retention.MaxPosts)), nil
+	case "retention.unread_window":
+		return c.Retention.UnreadWindow, nil
+	case "retention.prune_on_agg":
+		return strconv.FormatBool(c.Retention.PruneOnAgg), nil
+	case "fetch.connect_timeout":
+		return c.Fetch.ConnectTimeout, nil
+	case "fetch.read_timeout":
+		return c.Fetch.ReadTimeout, nil
+	case "fetch.max_body_size":
+		return strconv.FormatInt(c.Fetch.MaxBodySize, 10), nil
+	case "fetch.max_redirects":
+		if c.Fetch.MaxRedirects == nil {
+			return "", nil
+		}
+		return strconv.Itoa(*c.Fetch.MaxRedirects), nil
+	case "fetch.ca_bundle":
+		return c.Fetch.CABundle, nil
+	case "fetch.proxy":
+		return c.Fetch.Proxy, nil
+	case "fetch.allow":
+		return strings.Join(c.Fetch.Allow, ","), nil
+	case "fetch.deny":
+		return strings.Join(c.Fetch.Deny, ","), nil
+	case "fetch.host_interval":
+		return c.Fetch.HostInterval, nil
+	case "fetch.host_concurrency":
+		return strconv.Itoa(c.Fetch.HostConcurrency), nil
+	case "fetch.user_agent":
+		return c.Fetch.UserAgent, nil
+	case "fetch.contact_url":
+		return c.Fetch.ContactURL, nil
+	}
+
+	return "", fmt.Errorf("unknown config key %v", key)
+}
+
+// Set parses value and stores it in key.
+func (c *Config) Set(key string, value string) error {
+
+	switch key {
+	case "db_url":
+		c.Url = value
+
+	case "session_token":
+		c.SessionToken = value
+
+	case "retention.max_age", "retention.unread_window":
+		if value != "" {
+			if _, err := time.ParseDuration(value); err != nil {
+				return fmt.Errorf("%v must be a duration such as 720h: %w", key, err)
+			}
+		}
+		if key == "retention.max_age" {
+			c.Retention.MaxAge = value
+		} else {
+			c.Retention.UnreadWindow = value
+		}
+
+	case "retention.max_posts":
+		maxPosts, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || maxPosts < 0 {
+			return fmt.Errorf("%v must be a positive number, got %v", key, value)
+		}
+		c.Retention.MaxPosts = int32(maxPosts)
+
+	case "retention.prune_on_agg":
+		pruneOnAgg, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%v must be true or false, got %v", key, value)
+		}
+		c.Retention.PruneOnAgg = pruneOnAgg
+
+	case "fetch.connect_timeout", "fetch.read_timeout":
+		if value != "" {
+			if timeout, err := time.ParseDuration(value); err != nil || timeout <= 0 {
+				return fmt.Errorf("%v must be a positive duration such as 10s, got %v", key, value)
+			}
+		}
+		if key == "fetch.connect_timeout" {
+			c.Fetch.ConnectTimeout = value
+		} else {
+			c.Fetch.ReadTimeout = value
+		}
+
+	case "fetch.host_interval":
+		if value != "" {
+			if _, err := time.ParseDuration(value); err != nil {
+				return fmt.Errorf("%v must be a duration such as 1s: %w", key, err)
+			}
+		}
+		c.Fetch.HostInterval = value
+
+	case "fetch.host_concurrency":
+		hostConcurrency, err := strconv.Atoi(value)
+		if err != nil || hostConcurrency < 0 {
+			return fmt.Errorf("%v must be a positive number, got %v", key, value)
+		}
+		c.Fetch.HostConcurrency = hostConcurrency
+
+	case "fetch.user_agent":
+		c.Fetch.UserAgent = value
+
+	case "fetch.contact_url":
+		if value != "" {
+			if contactURL, err := url.Parse(value); err != nil || contactURL.Scheme == "" {
+				return fmt.Errorf("%v must be a url such as https://example.com/gator or mailto:me@example.com, got %v", key, value)
+			}
+		}
+		c.Fetch.ContactURL = value
+
+	case "fetch.max_body_size":
+		maxBodySize, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || maxBodySize < 0 {
+			return fmt.Errorf("%v must be a positive number of bytes, got %v", key, value)
+		}
+		c.Fetch.MaxBodySize = maxBodySize
+
+	case "fetch.max_redirects":
+		if value == "" {
+			c.Fetch.MaxRedirects = nil
+			break
+		}
+		maxRedirects, err := strconv.Atoi(value)
+		if err != nil || maxRedirects < 0 {
+			return fmt.Errorf("%v must be a positive number, got %v", key, value)
+		}
+		c.Fetch.MaxRedirects = &maxRedirects
+
+	case "fetch.ca_bundle":
+		c.Fetch.CABundle = value
+
+	case "fetch.proxy":
+		if value != "" && value != "none" {
+			if proxyURL, err := url.Parse(value); err != nil || proxyURL.Host == "" {
+				return fmt.Errorf("%v must be a url such as http://proxy:3128 or none, got %v", key, value)
+			}
+		}
+		c.Fetch.Proxy = value
+
+	case "fetch.allow", "fetch.deny":
+		var ranges []string
+		for _, addressRange := range strings.Split(value, ",") {
+			addressRange = strings.TrimSpace(addressRange)
+			if addressRange == "" {
+				continue
+			}
+			if _, err := ParseAddressRange(addressRange); err != nil {
+				return fmt.Errorf("%v: %w", key, err)
+			}
+			ranges = append(ranges, addressRange)
+		}
+		if key == "fetch.allow" {
+			c.Fetch.Allow = ranges
+		} else {
+			c.Fetch.Deny = ranges
+		}
+
+	default:
+		return fmt.Errorf("unknown config key %v", key)
+	}
+
+	return nil
+}
+
+// ParseAddressRange parses a CIDR range such as 10.0.0.0/8, or a single
+// address standing for a range of one.
+func ParseAddressRange(value string) (netip.Prefix, error) {
+
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%v is not an address or CIDR range", value)
+		}
+		return prefix.Masked(), nil
+	}
+
+	address, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%v is not an address or CIDR range", value)
+	}
+
+	address = address.Unmap()
+
+	return netip.PrefixFrom(address, address.BitLen()), nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import "testing"
+
+func TestSet(t *testing.T) {
+
+	tests := []struct {
+		key     string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{key: "db_url", value: "postgres://localhost/gator", want: "postgres://localhost/gator"},
+		{key: "session_token", value: "token", want: "token"},
+		{key: "retention.max_age", value: "720h", want: "720h"},
+		{key: "retention.max_age", value: "", want: ""},
+		{key: "retention.max_age", value: "30 days", wantErr: true},
+		{key: "retention.unread_window", value: "168h", want: "168h"},
+		{key: "retention.max_posts", value: "100", want: "100"},
+		{key: "retention.max_posts", value: "-1", wantErr: true},
+		{key: "retention.max_posts", value: "3000000000", wantErr: true},
+		{key: "retention.prune_on_agg", value: "true", want: "true"},
+		{key: "retention.prune_on_agg", value: "sometimes", wantErr: true},
+		{key: "fetch.connect_timeout", value: "5s", want: "5s"},
+		{key: "fetch.connect_timeout", value: "0s", wantErr: true},
+		{key: "fetch.read_timeout", value: "-1s", wantErr: true},
+		{key: "fetch.max_body_size", value: "1048576", want: "1048576"},
+		{key: "fetch.max_body_size", value: "1MB", wantErr: true},
+		{key: "fetch.max_redirects", value: "0", want: "0"},
+		{key: "fetch.max_redirects", value: "", want: ""},
+		{key: "fetch.max_redirects", value: "-1", wantErr: true},
+		{key: "fetch.proxy", value: "http://proxy:3128", want: "http://proxy:3128"},
+		{key: "fetch.proxy", value: "none", want: "none"},
+		{key: "fetch.proxy", value: "proxy", wantErr: true},
+		{key: "fetch.allow", value: "127.0.0.1, 10.0.0.0/8,", want: "127.0.0.1,10.0.0.0/8"},
+		{key: "fetch.allow", value: "", want: ""},
+		{key: "fetch.deny", value: "203.0.113.0/24", want: "203.0.113.0/24"},
+		{key: "fetch.deny", value: "example.com", wantErr: true},
+		{key: "fetch.host_interval", value: "2s", want: "2s"},
+		{key: "fetch.host_interval", value: "often", wantErr: true},
+		{key: "fetch.host_concurrency", value: "4", want: "4"},
+		{key: "fetch.host_concurrency", value: "many", wantErr: true},
+		{key: "fetch.contact_url", value: "mailto:me@example.com", want: "mailto:me@example.com"},
+		{key: "fetch.contact_url", value: "example.com", wantErr: true},
+		{key: "no_such_key", value: "1", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key+"="+test.value, func(t *testing.T) {
+
+			var cfg Config
+
+			err := cfg.Set(test.key, test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("Set(%v, %q) succeeded, want an error", test.key, test.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set(%v, %q) = %v", test.key, test.value, err)
+			}
+
+			got, err := cfg.Get(test.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("Get(%v) = %q, want %q", test.key, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseAddressRange(t *testing.T) {
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "127.0.0.1", want: "127.0.0.1/32"},
+		{value: "::1", want: "::1/128"},
+		{value: "::ffff:10.0.0.1", want: "10.0.0.1/32"},
+		{value: "10.1.2.3/8", want: "10.0.0.0/8"},
+		{value: "fd00::/8", want: "fd00::/8"},
+		{value: "10.0.0.0/33", wantErr: true},
+		{value: "localhost", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+
+			prefix, err := ParseAddressRange(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("ParseAddressRange(%q) = %v, want an error", test.value, prefix)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prefix.String() != test.want {
+				t.Errorf("ParseAddressRange(%q) = %v, want %v", test.value, prefix, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	lockRetryInterval = 50 * time.Millisecond
+	lockTimeout       = 5 * time.Second
+
+	// A lock older than this was left behind by a process that died while
+	// writing, which never takes more than a moment.
+	staleLockAge = 30 * time.Second
+)
+
+// lock takes an exclusive lock on the config file at path by creating
+// path.lock, and returns the function releasing it. Creating the file with
+// O_EXCL works the same on every platform.
+func lock(path string) (func(), error) {
+
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(lockFile, "%d\n", os.Getpid())
+			lockFile.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("config is locked by another gator process, remove %v if none is running", lockPath)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
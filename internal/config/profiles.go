@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Profiles returns the names of every profile, sorted, and the name of the
+// current one.
+func Profiles() ([]string, string, error) {
+
+	cfgFile, err := loadFile()
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := make([]string, 0, len(cfgFile.Profiles))
+	for name := range cfgFile.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, cfgFile.CurrentProfile, nil
+}
+
+// UseProfile makes name the profile used when none is selected.
+func UseProfile(name string) error {
+
+	return updateFile(func(cfgFile *file) error {
+
+		if _, exists := cfgFile.Profiles[name]; !exists {
+			return fmt.Errorf("profile %v does not exist", name)
+		}
+
+		cfgFile.CurrentProfile = name
+
+		return nil
+	})
+}
+
+func AddProfile(name string, dbURL string) error {
+
+	return updateFile(func(cfgFile *file) error {
+
+		if _, exists := cfgFile.Profiles[name]; exists {
+			return fmt.Errorf("profile %v already exists", name)
+		}
+
+		cfgFile.Profiles[name] = Config{Url: dbURL}
+
+		return nil
+	})
+}
+
+func RemoveProfile(name string) error {
+
+	return updateFile(func(cfgFile *file) error {
+
+		if _, exists := cfgFile.Profiles[name]; !exists {
+			return fmt.Errorf("profile %v does not exist", name)
+		}
+
+		if name == cfgFile.CurrentProfile {
+			return fmt.Errorf("profile %v is in use, switch to another profile first", name)
+		}
+
+		delete(cfgFile.Profiles, name)
+
+		return nil
+	})
+}
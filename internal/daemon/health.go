@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const pingTimeout = 2 * time.Second
+
+// Health tracks whether the aggregator is doing its job. It is ready while
+// the database answers and a fetch succeeded within the last window, with
+// the first window after starting given as grace.
+type Health struct {
+	ping   func(context.Context) error
+	window time.Duration
+
+	mu          sync.Mutex
+	started     time.Time
+	lastSuccess time.Time
+	lastError   error
+}
+
+func NewHealth(ping func(context.Context) error, window time.Duration) *Health {
+	return &Health{
+		ping:    ping,
+		window:  window,
+		started: time.Now(),
+	}
+}
+
+// RecordFetch notes the outcome of a fetch.
+func (h *Health) RecordFetch(err error) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err != nil {
+		h.lastError = err
+		return
+	}
+
+	h.lastSuccess = time.Now()
+	h.lastError = nil
+}
+
+// Ready reports why the aggregator isn't ready, or nil if it is.
+func (h *Health) Ready(ctx context.Context) error {
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	if err := h.ping(ctx); err != nil {
+		return fmt.Errorf("database unreachable: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if time.Since(h.lastSuccess) <= h.window || time.Since(h.started) <= h.window {
+		return nil
+	}
+
+	if h.lastSuccess.IsZero() {
+		return fmt.Errorf("no successful fetch since starting %v ago (last error: %v)", time.Since(h.started).Round(time.Second), h.lastError)
+	}
+
+	return fmt.Errorf("no successful fetch for %v (last error: %v)", time.Since(h.lastSuccess).Round(time.Second), h.lastError)
+}
+
+// Register adds /healthz, answering as long as the process serves
+// requests, and /readyz, answering with Ready, to mux.
+func (h *Health) Register(mux *http.ServeMux) {
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := h.Ready(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
@@ -0,0 +1,95 @@
+// Package daemon holds what agg needs to run as a long-lived service: a
+// PID file keeping a single aggregator per host and the health endpoints.
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// DefaultPIDPath is where agg writes its PID file for profile unless told
+// otherwise, so each profile gets its own aggregator.
+func DefaultPIDPath(profile string) (string, error) {
+
+	homePath, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homePath, ".gator", "agg-"+profile+".pid"), nil
+}
+
+// AcquirePIDFile creates the PID file at path holding the current process
+// id, and returns the function removing it. It fails if the file names a
+// process that is still running; a file left behind by one that died is
+// replaced.
+func AcquirePIDFile(path string) (func(), error) {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+
+		pidFile, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, err = fmt.Fprintf(pidFile, "%d\n", os.Getpid())
+			if closeErr := pidFile.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		pid, err := readPID(path)
+		if err == nil && processRunning(pid) {
+			return nil, fmt.Errorf("agg is already running with pid %d, see %v", pid, path)
+		}
+
+		if attempt > 0 {
+			return nil, fmt.Errorf("couldn't replace stale pid file %v", path)
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+}
+
+func readPID(path string) (int, error) {
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
+
+func processRunning(pid int) bool {
+
+	if pid <= 0 {
+		return false
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	err = process.Signal(syscall.Signal(0))
+
+	// EPERM means the process exists but belongs to someone else.
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcquirePIDFile(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "gator", "agg.pid")
+
+	release, err := AcquirePIDFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("pid file mode is %v, want -rw-------", mode)
+	}
+
+	if _, err := AcquirePIDFile(path); err == nil {
+		t.Error("a second agg got the pid file")
+	}
+
+	release()
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("pid file still there after release: %v", err)
+	}
+}
+
+func TestAcquireStalePIDFile(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "agg.pid")
+
+	// No process runs with a pid this large.
+	if err := os.WriteFile(path, []byte("2147483647\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := AcquirePIDFile(path)
+	if err != nil {
+		t.Fatalf("stale pid file was not replaced: %v", err)
+	}
+	release()
+}
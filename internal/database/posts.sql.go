@@ -8,8 +8,10 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :one
@@ -59,22 +61,361 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 	return i, err
 }
 
+const deleteAllPosts = `-- name: DeleteAllPosts :exec
+DELETE FROM posts
+`
+
+func (q *Queries) DeleteAllPosts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllPosts)
+	return err
+}
+
+const deletePosts = `-- name: DeletePosts :exec
+DELETE FROM posts
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) DeletePosts(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePosts, pq.Array(ids))
+	return err
+}
+
+const getFollowedPostsForUser = `-- name: GetFollowedPostsForUser :many
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id,
+    feeds.name AS feed_name,
+    feeds.url AS feed_url
+FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY posts.created_at
+`
+
+type GetFollowedPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	FeedName    string
+	FeedUrl     string
+}
+
+func (q *Queries) GetFollowedPostsForUser(ctx context.Context, userID uuid.UUID) ([]GetFollowedPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedPostsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowedPostsForUserRow
+	for rows.Next() {
+		var i GetFollowedPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeedName,
+			&i.FeedUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByURL = `-- name: GetPostByURL :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+WHERE url = $1
+`
+
+func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByURL, url)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+	)
+	return i, err
+}
+
+const getPostFromID = `-- name: GetPostFromID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+WHERE id = $1
+`
+
+func (q *Queries) GetPostFromID(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostFromID, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+	)
+	return i, err
+}
+
+const getPosts = `-- name: GetPosts :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+ORDER BY created_at
+`
+
+func (q *Queries) GetPosts(ctx context.Context) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForFeedForUser = `-- name: GetPostsForFeedForUser :many
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id,
+    post_states.read_at,
+    post_states.saved_at
+FROM posts
+LEFT JOIN post_states
+ON post_states.post_id = posts.id
+AND post_states.user_id = $1
+WHERE posts.feed_id = $2
+AND post_states.hidden_at IS NULL
+ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC
+`
+
+type GetPostsForFeedForUserParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+type GetPostsForFeedForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	ReadAt      sql.NullTime
+	SavedAt     sql.NullTime
+}
+
+func (q *Queries) GetPostsForFeedForUser(ctx context.Context, arg GetPostsForFeedForUserParams) ([]GetPostsForFeedForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForFeedForUser, arg.UserID, arg.FeedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForFeedForUserRow
+	for rows.Next() {
+		var i GetPostsForFeedForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.ReadAt,
+			&i.SavedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id FROM posts
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name
+FROM posts
 INNER JOIN feed_follows
 ON feed_follows.feed_id = posts.feed_id 
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
 WHERE feed_follows.user_id = $1
+AND ($2::uuid IS NULL OR feed_follows.folder_id = $2)
+AND ($3::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    INNER JOIN tags
+    ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id
+    AND tags.user_id = $1
+    AND tags.name = $3
+))
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.user_id = $1
+    AND post_states.hidden_at IS NOT NULL
+)
 ORDER BY posts.created_at DESC
-LIMIT $2
+LIMIT $4
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID   uuid.UUID
+	FolderID uuid.NullUUID
+	Tag      sql.NullString
+	Limit    int32
+}
+
+type GetPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	FeedName    string
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.FolderID,
+		arg.Tag,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserRow
+	for rows.Next() {
+		var i GetPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPrunablePostsForFeed = `-- name: GetPrunablePostsForFeed :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id FROM posts
+WHERE posts.feed_id = $1
+AND (
+    COALESCE(posts.published_at, posts.created_at) < $2::timestamp
+    OR posts.id NOT IN (
+        SELECT newest.id FROM posts AS newest
+        WHERE newest.feed_id = $1
+        ORDER BY COALESCE(newest.published_at, newest.created_at) DESC
+        LIMIT $3::int
+    )
+)
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.saved_at IS NOT NULL
+)
+AND NOT (
+    posts.created_at >= $4::timestamp
+    AND EXISTS (
+        SELECT 1 FROM feed_follows
+        LEFT JOIN post_states
+        ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+        WHERE feed_follows.feed_id = posts.feed_id
+        AND post_states.read_at IS NULL
+    )
+)
+ORDER BY COALESCE(posts.published_at, posts.created_at) ASC
+`
+
+type GetPrunablePostsForFeedParams struct {
+	FeedID      uuid.UUID
+	OlderThan   sql.NullTime
+	MaxPosts    sql.NullInt32
+	UnreadSince time.Time
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+func (q *Queries) GetPrunablePostsForFeed(ctx context.Context, arg GetPrunablePostsForFeedParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPrunablePostsForFeed,
+		arg.FeedID,
+		arg.OlderThan,
+		arg.MaxPosts,
+		arg.UnreadSince,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -104,3 +445,46 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const restorePost = `-- name: RestorePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+ON CONFLICT DO NOTHING
+`
+
+type RestorePostParams struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+}
+
+func (q *Queries) RestorePost(ctx context.Context, arg RestorePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restorePost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
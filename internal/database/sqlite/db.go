@@ -0,0 +1,122 @@
+// Package sqlite implements database.Querier on top of an embedded SQLite
+// database. The queries mirror the sqlc-generated Postgres ones in
+// sql/queries, rewritten for SQLite where the dialects differ.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/mattn/go-sqlite3"
+)
+
+const driverName = "gator_sqlite3"
+
+// timeFormat is the layout go-sqlite3 writes time.Time values with. All
+// timestamps are stored in UTC so that they compare correctly as text.
+const timeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("now", func() string {
+				return time.Now().UTC().Format(timeFormat)
+			}, false)
+		},
+	})
+}
+
+// Open opens the SQLite database file at path with foreign keys enforced.
+func Open(path string) (*sql.DB, error) {
+
+	db, err := sql.Open(driverName, "file:"+path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer; one connection avoids "database is
+	// locked" errors between concurrent statements of the same process.
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+func New(db database.DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+type Queries struct {
+	db database.DBTX
+}
+
+var _ database.Querier = (*Queries)(nil)
+
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return &Queries{
+		db: tx,
+	}
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (q *Queries) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return q.db.ExecContext(ctx, query, utc(args)...)
+}
+
+func (q *Queries) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return q.db.QueryRowContext(ctx, query, utc(args)...)
+}
+
+// queryAll runs query and scans every row with scan.
+func queryAll[T any](ctx context.Context, q *Queries, scan func(scanner) (T, error), query string, args ...interface{}) ([]T, error) {
+	rows, err := q.db.QueryContext(ctx, query, utc(args)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []T
+	for rows.Next() {
+		i, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// utc converts time arguments to UTC so stored timestamps share one offset.
+func utc(args []interface{}) []interface{} {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case sql.NullTime:
+			if v.Valid {
+				args[i] = sql.NullTime{Time: v.Time.UTC(), Valid: true}
+			}
+		}
+	}
+	return args
+}
+
+// expandSlice replaces the /*SLICE:name*/? marker in query with one
+// placeholder per value, the way sqlc handles sqlc.slice for SQLite.
+func expandSlice(query string, name string, count int) string {
+	placeholders := "NULL"
+	if count > 0 {
+		placeholders = strings.Repeat(",?", count)[1:]
+	}
+	return strings.Replace(query, "/*SLICE:"+name+"*/?", placeholders, 1)
+}
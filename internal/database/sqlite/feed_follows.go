@@ -0,0 +1,231 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func scanFeedFollow(row scanner) (database.FeedFollow, error) {
+	var i database.FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+		&i.Title,
+	)
+	return i, err
+}
+
+// SQLite has no data-modifying CTEs, so the follow is inserted first and
+// then read back joined with its feed and user.
+const createFeedFollow = `
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES (?1, ?2, ?3, ?4, ?5)
+`
+
+const getCreatedFeedFollow = `
+SELECT
+    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.folder_id, feed_follows.title,
+    feeds.name AS feed_name,
+    users.name AS user_name
+FROM feed_follows
+INNER JOIN feeds
+ON feed_follows.feed_id = feeds.id
+INNER JOIN users
+ON feed_follows.user_id = users.id
+WHERE feed_follows.id = ?1
+`
+
+func (q *Queries) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	var i database.CreateFeedFollowRow
+	_, err := q.exec(ctx, createFeedFollow,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+	)
+	if err != nil {
+		return i, err
+	}
+	err = q.queryRow(ctx, getCreatedFeedFollow, arg.ID).Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+		&i.Title,
+		&i.FeedName,
+		&i.UserName,
+	)
+	return i, err
+}
+
+const getFeedFollowsForUser = `
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id, title FROM feed_follows
+WHERE user_id = ?1
+`
+
+func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.FeedFollow, error) {
+	return queryAll(ctx, q, scanFeedFollow, getFeedFollowsForUser, userID)
+}
+
+const getFollowedFeedsForUser = `
+SELECT
+    feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.next_fetch_at,
+    COALESCE(feed_follows.title, feeds.name) AS display_name,
+    folders.name AS folder_name
+FROM feed_follows
+INNER JOIN feeds
+ON feed_follows.feed_id = feeds.id
+LEFT JOIN folders
+ON feed_follows.folder_id = folders.id
+WHERE feed_follows.user_id = ?1
+ORDER BY folders.name NULLS FIRST, display_name
+`
+
+func scanFollowedFeed(row scanner) (database.GetFollowedFeedsForUserRow, error) {
+	var i database.GetFollowedFeedsForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.DisplayName,
+		&i.FolderName,
+	)
+	return i, err
+}
+
+func (q *Queries) GetFollowedFeedsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFollowedFeedsForUserRow, error) {
+	return queryAll(ctx, q, scanFollowedFeed, getFollowedFeedsForUser, userID)
+}
+
+const setFeedFollowFolder = `
+UPDATE feed_follows
+SET folder_id = ?3, updated_at = NOW()
+WHERE user_id = ?1 AND feed_id = ?2
+`
+
+func (q *Queries) SetFeedFollowFolder(ctx context.Context, arg database.SetFeedFollowFolderParams) (int64, error) {
+	result, err := q.exec(ctx, setFeedFollowFolder, arg.UserID, arg.FeedID, arg.FolderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedFollowTitle = `
+UPDATE feed_follows
+SET title = ?3, updated_at = NOW()
+WHERE user_id = ?1 AND feed_id = ?2
+`
+
+func (q *Queries) SetFeedFollowTitle(ctx context.Context, arg database.SetFeedFollowTitleParams) (int64, error) {
+	result, err := q.exec(ctx, setFeedFollowTitle, arg.UserID, arg.FeedID, arg.Title)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unfollowFeed = `
+DELETE FROM feed_follows WHERE user_id = ?1 AND feed_id = ?2
+`
+
+func (q *Queries) UnfollowFeed(ctx context.Context, arg database.UnfollowFeedParams) error {
+	_, err := q.exec(ctx, unfollowFeed, arg.UserID, arg.FeedID)
+	return err
+}
+
+const getFeedFollows = `
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id, title FROM feed_follows
+ORDER BY created_at
+`
+
+func (q *Queries) GetFeedFollows(ctx context.Context) ([]database.FeedFollow, error) {
+	return queryAll(ctx, q, scanFeedFollow, getFeedFollows)
+}
+
+const restoreFeedFollow = `
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder_id, title)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) RestoreFeedFollow(ctx context.Context, arg database.RestoreFeedFollowParams) (int64, error) {
+	result, err := q.exec(ctx, restoreFeedFollow,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+		arg.FolderID,
+		arg.Title,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedFollowsForFeed = `
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id, title FROM feed_follows
+WHERE feed_id = ?1
+ORDER BY created_at
+`
+
+func (q *Queries) GetFeedFollowsForFeed(ctx context.Context, feedID uuid.UUID) ([]database.FeedFollow, error) {
+	return queryAll(ctx, q, scanFeedFollow, getFeedFollowsForFeed, feedID)
+}
+
+func scanGetFeedsWithUnreadCountForUserRow(row scanner) (database.GetFeedsWithUnreadCountForUserRow, error) {
+	var i database.GetFeedsWithUnreadCountForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.DisplayName,
+		&i.FolderName,
+		&i.UnreadCount,
+	)
+	return i, err
+}
+
+const getFeedsWithUnreadCountForUser = `
+SELECT
+    feeds.id,
+    feeds.url,
+    COALESCE(feed_follows.title, feeds.name) AS display_name,
+    folders.name AS folder_name,
+    COUNT(posts.id) AS unread_count
+FROM feed_follows
+INNER JOIN feeds
+ON feeds.id = feed_follows.feed_id
+LEFT JOIN folders
+ON folders.id = feed_follows.folder_id
+LEFT JOIN posts
+ON posts.feed_id = feeds.id
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.user_id = ?1
+    AND (post_states.read_at IS NOT NULL OR post_states.hidden_at IS NOT NULL)
+)
+WHERE feed_follows.user_id = ?1
+GROUP BY feeds.id, feeds.url, feeds.name, feed_follows.title, folders.name
+ORDER BY folders.name NULLS FIRST, display_name
+`
+
+func (q *Queries) GetFeedsWithUnreadCountForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedsWithUnreadCountForUserRow, error) {
+	return queryAll(ctx, q, scanGetFeedsWithUnreadCountForUserRow, getFeedsWithUnreadCountForUser, userID)
+}
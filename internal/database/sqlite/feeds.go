@@ -0,0 +1,184 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func scanFeed(row scanner) (database.Feed, error) {
+	var i database.Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.NextFetchAt,
+	)
+	return i, err
+}
+
+const createFeed = `
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at
+`
+
+func (q *Queries) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	return scanFeed(q.queryRow(ctx, createFeed,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.Url,
+		arg.UserID,
+	))
+}
+
+const getFeed = `
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at FROM feeds
+WHERE url = ?1
+`
+
+func (q *Queries) GetFeed(ctx context.Context, url string) (database.Feed, error) {
+	return scanFeed(q.queryRow(ctx, getFeed, url))
+}
+
+const getFeedFromID = `
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at FROM feeds
+WHERE id = ?1
+`
+
+func (q *Queries) GetFeedFromID(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	return scanFeed(q.queryRow(ctx, getFeedFromID, id))
+}
+
+const getFeeds = `
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at FROM feeds
+`
+
+func (q *Queries) GetFeeds(ctx context.Context) ([]database.Feed, error) {
+	return queryAll(ctx, q, scanFeed, getFeeds)
+}
+
+const getNextFeedToFetch = `
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at FROM feeds
+WHERE next_fetch_at IS NULL OR next_fetch_at <= ?1
+ORDER BY last_fetched_at ASC NULLS FIRST
+LIMIT 1
+`
+
+func (q *Queries) GetNextFeedToFetch(ctx context.Context, now time.Time) (database.Feed, error) {
+	return scanFeed(q.queryRow(ctx, getNextFeedToFetch, now))
+}
+
+const markFeedFetched = `
+UPDATE feeds
+SET last_fetched_at = ?1, next_fetch_at = NULL, updated_at = NOW()
+WHERE id = ?2
+`
+
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	_, err := q.exec(ctx, markFeedFetched, arg.FetchedAt, arg.ID)
+	return err
+}
+
+const deleteAllFeeds = `
+DELETE FROM feeds
+`
+
+func (q *Queries) DeleteAllFeeds(ctx context.Context) error {
+	_, err := q.exec(ctx, deleteAllFeeds)
+	return err
+}
+
+const deleteFeedsForUser = `
+DELETE FROM feeds
+WHERE user_id = ?1
+`
+
+func (q *Queries) DeleteFeedsForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.exec(ctx, deleteFeedsForUser, userID)
+	return err
+}
+
+const restoreFeed = `
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) RestoreFeed(ctx context.Context, arg database.RestoreFeedParams) (int64, error) {
+	result, err := q.exec(ctx, restoreFeed,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.Url,
+		arg.UserID,
+		arg.LastFetchedAt,
+		arg.NextFetchAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFeed = `
+UPDATE feeds
+SET name = ?2, url = ?3, updated_at = NOW()
+WHERE id = ?1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at
+`
+
+func (q *Queries) UpdateFeed(ctx context.Context, arg database.UpdateFeedParams) (database.Feed, error) {
+	return scanFeed(q.queryRow(ctx, updateFeed, arg.ID, arg.Name, arg.Url))
+}
+
+const setFeedOwner = `
+UPDATE feeds
+SET user_id = ?2, updated_at = NOW()
+WHERE id = ?1
+`
+
+func (q *Queries) SetFeedOwner(ctx context.Context, arg database.SetFeedOwnerParams) error {
+	_, err := q.exec(ctx, setFeedOwner, arg.ID, arg.UserID)
+	return err
+}
+
+const deleteFeed = `
+DELETE FROM feeds
+WHERE id = ?1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.exec(ctx, deleteFeed, id)
+	return err
+}
+
+const delayFeedFetch = `
+UPDATE feeds
+SET next_fetch_at = ?2, updated_at = NOW()
+WHERE id = ?1
+`
+
+func (q *Queries) DelayFeedFetch(ctx context.Context, arg database.DelayFeedFetchParams) error {
+	_, err := q.exec(ctx, delayFeedFetch, arg.ID, arg.NextFetchAt)
+	return err
+}
+
+const getFeedsForUser = `
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at FROM feeds
+WHERE user_id = ?1
+ORDER BY created_at
+`
+
+func (q *Queries) GetFeedsForUser(ctx context.Context, userID uuid.UUID) ([]database.Feed, error) {
+	return queryAll(ctx, q, scanFeed, getFeedsForUser, userID)
+}
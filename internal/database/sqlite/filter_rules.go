@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func scanFilterRule(row scanner) (database.FilterRule, error) {
+	var i database.FilterRule
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Field,
+		&i.Pattern,
+		&i.IsRegex,
+		&i.Action,
+		&i.Tag,
+	)
+	return i, err
+}
+
+const createFilterRule = `
+INSERT INTO filter_rules (id, created_at, updated_at, user_id, field, pattern, is_regex, action, tag)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)
+RETURNING id, created_at, updated_at, user_id, field, pattern, is_regex, action, tag
+`
+
+func (q *Queries) CreateFilterRule(ctx context.Context, arg database.CreateFilterRuleParams) (database.FilterRule, error) {
+	return scanFilterRule(q.queryRow(ctx, createFilterRule,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.Field,
+		arg.Pattern,
+		arg.IsRegex,
+		arg.Action,
+		arg.Tag,
+	))
+}
+
+const deleteFilterRule = `
+DELETE FROM filter_rules
+WHERE id = ?1 AND user_id = ?2
+`
+
+func (q *Queries) DeleteFilterRule(ctx context.Context, arg database.DeleteFilterRuleParams) (int64, error) {
+	result, err := q.exec(ctx, deleteFilterRule, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFilterRulesForFeed = `
+SELECT filter_rules.id, filter_rules.created_at, filter_rules.updated_at, filter_rules.user_id, filter_rules.field, filter_rules.pattern, filter_rules.is_regex, filter_rules.action, filter_rules.tag FROM filter_rules
+INNER JOIN feed_follows
+ON feed_follows.user_id = filter_rules.user_id
+WHERE feed_follows.feed_id = ?1
+ORDER BY filter_rules.created_at
+`
+
+func (q *Queries) GetFilterRulesForFeed(ctx context.Context, feedID uuid.UUID) ([]database.FilterRule, error) {
+	return queryAll(ctx, q, scanFilterRule, getFilterRulesForFeed, feedID)
+}
+
+const getFilterRulesForUser = `
+SELECT id, created_at, updated_at, user_id, field, pattern, is_regex, action, tag FROM filter_rules
+WHERE user_id = ?1
+ORDER BY created_at
+`
+
+func (q *Queries) GetFilterRulesForUser(ctx context.Context, userID uuid.UUID) ([]database.FilterRule, error) {
+	return queryAll(ctx, q, scanFilterRule, getFilterRulesForUser, userID)
+}
+
+const getFilterRules = `
+SELECT id, created_at, updated_at, user_id, field, pattern, is_regex, action, tag FROM filter_rules
+ORDER BY created_at
+`
+
+func (q *Queries) GetFilterRules(ctx context.Context) ([]database.FilterRule, error) {
+	return queryAll(ctx, q, scanFilterRule, getFilterRules)
+}
+
+const restoreFilterRule = `
+INSERT INTO filter_rules (id, created_at, updated_at, user_id, field, pattern, is_regex, action, tag)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) RestoreFilterRule(ctx context.Context, arg database.RestoreFilterRuleParams) (int64, error) {
+	result, err := q.exec(ctx, restoreFilterRule,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.Field,
+		arg.Pattern,
+		arg.IsRegex,
+		arg.Action,
+		arg.Tag,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
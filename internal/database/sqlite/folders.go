@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func scanFolder(row scanner) (database.Folder, error) {
+	var i database.Folder
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Name,
+	)
+	return i, err
+}
+
+const createFolder = `
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES (?1, ?2, ?3, ?4, ?5)
+RETURNING id, created_at, updated_at, user_id, name
+`
+
+func (q *Queries) CreateFolder(ctx context.Context, arg database.CreateFolderParams) (database.Folder, error) {
+	return scanFolder(q.queryRow(ctx, createFolder,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.Name,
+	))
+}
+
+const deleteFolder = `
+DELETE FROM folders
+WHERE id = ?1
+`
+
+func (q *Queries) DeleteFolder(ctx context.Context, id uuid.UUID) error {
+	_, err := q.exec(ctx, deleteFolder, id)
+	return err
+}
+
+const getFolder = `
+SELECT id, created_at, updated_at, user_id, name FROM folders
+WHERE user_id = ?1 AND name = ?2
+`
+
+func (q *Queries) GetFolder(ctx context.Context, arg database.GetFolderParams) (database.Folder, error) {
+	return scanFolder(q.queryRow(ctx, getFolder, arg.UserID, arg.Name))
+}
+
+const getFoldersForUser = `
+SELECT id, created_at, updated_at, user_id, name FROM folders
+WHERE user_id = ?1
+ORDER BY name
+`
+
+func (q *Queries) GetFoldersForUser(ctx context.Context, userID uuid.UUID) ([]database.Folder, error) {
+	return queryAll(ctx, q, scanFolder, getFoldersForUser, userID)
+}
+
+const renameFolder = `
+UPDATE folders
+SET name = ?2, updated_at = NOW()
+WHERE id = ?1
+`
+
+func (q *Queries) RenameFolder(ctx context.Context, arg database.RenameFolderParams) error {
+	_, err := q.exec(ctx, renameFolder, arg.ID, arg.Name)
+	return err
+}
+
+const getFolders = `
+SELECT id, created_at, updated_at, user_id, name FROM folders
+ORDER BY created_at
+`
+
+func (q *Queries) GetFolders(ctx context.Context) ([]database.Folder, error) {
+	return queryAll(ctx, q, scanFolder, getFolders)
+}
+
+const restoreFolder = `
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES (?1, ?2, ?3, ?4, ?5)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) RestoreFolder(ctx context.Context, arg database.RestoreFolderParams) (int64, error) {
+	result, err := q.exec(ctx, restoreFolder,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.Name,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
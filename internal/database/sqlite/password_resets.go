@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const createPasswordReset = `
+INSERT INTO password_resets (token_hash, user_id, created_at, expires_at)
+VALUES (?1, ?2, ?3, ?4)
+`
+
+func (q *Queries) CreatePasswordReset(ctx context.Context, arg database.CreatePasswordResetParams) error {
+	_, err := q.exec(ctx, createPasswordReset,
+		arg.TokenHash,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const getUserFromPasswordReset = `
+SELECT users.id, users.created_at, users.updated_at, users.name, users.password_hash, users.role FROM password_resets
+INNER JOIN users ON password_resets.user_id = users.id
+WHERE password_resets.token_hash = ?1 AND password_resets.expires_at > ?2
+`
+
+func (q *Queries) GetUserFromPasswordReset(ctx context.Context, arg database.GetUserFromPasswordResetParams) (database.User, error) {
+	return scanUser(q.queryRow(ctx, getUserFromPasswordReset, arg.TokenHash, arg.ExpiresAt))
+}
+
+const deletePasswordResetsForUser = `
+DELETE FROM password_resets
+WHERE user_id = ?1
+`
+
+func (q *Queries) DeletePasswordResetsForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.exec(ctx, deletePasswordResetsForUser, userID)
+	return err
+}
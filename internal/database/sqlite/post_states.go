@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/Omorfii/aggregator/internal/database"
+)
+
+func scanPostState(row scanner) (database.PostState, error) {
+	var i database.PostState
+	err := row.Scan(
+		&i.UserID,
+		&i.PostID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReadAt,
+		&i.SavedAt,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const hidePost = `
+INSERT INTO post_states (user_id, post_id, created_at, updated_at, hidden_at)
+VALUES (?1, ?2, NOW(), NOW(), NOW())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET hidden_at = NOW(), updated_at = NOW()
+`
+
+func (q *Queries) HidePost(ctx context.Context, arg database.HidePostParams) error {
+	_, err := q.exec(ctx, hidePost, arg.UserID, arg.PostID)
+	return err
+}
+
+const markPostRead = `
+INSERT INTO post_states (user_id, post_id, created_at, updated_at, read_at)
+VALUES (?1, ?2, NOW(), NOW(), NOW())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET read_at = NOW(), updated_at = NOW()
+`
+
+func (q *Queries) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	_, err := q.exec(ctx, markPostRead, arg.UserID, arg.PostID)
+	return err
+}
+
+const markPostUnread = `
+UPDATE post_states
+SET read_at = NULL, updated_at = NOW()
+WHERE user_id = ?1 AND post_id = ?2
+`
+
+func (q *Queries) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error {
+	_, err := q.exec(ctx, markPostUnread, arg.UserID, arg.PostID)
+	return err
+}
+
+const savePost = `
+INSERT INTO post_states (user_id, post_id, created_at, updated_at, saved_at)
+VALUES (?1, ?2, NOW(), NOW(), NOW())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET saved_at = NOW(), updated_at = NOW()
+`
+
+func (q *Queries) SavePost(ctx context.Context, arg database.SavePostParams) error {
+	_, err := q.exec(ctx, savePost, arg.UserID, arg.PostID)
+	return err
+}
+
+const unsavePost = `
+UPDATE post_states
+SET saved_at = NULL, updated_at = NOW()
+WHERE user_id = ?1 AND post_id = ?2
+`
+
+func (q *Queries) UnsavePost(ctx context.Context, arg database.UnsavePostParams) error {
+	_, err := q.exec(ctx, unsavePost, arg.UserID, arg.PostID)
+	return err
+}
+
+const getPostStates = `
+SELECT user_id, post_id, created_at, updated_at, read_at, saved_at, hidden_at FROM post_states
+`
+
+func (q *Queries) GetPostStates(ctx context.Context) ([]database.PostState, error) {
+	return queryAll(ctx, q, scanPostState, getPostStates)
+}
+
+const restorePostState = `
+INSERT INTO post_states (user_id, post_id, created_at, updated_at, read_at, saved_at, hidden_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) RestorePostState(ctx context.Context, arg database.RestorePostStateParams) (int64, error) {
+	result, err := q.exec(ctx, restorePostState,
+		arg.UserID,
+		arg.PostID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.ReadAt,
+		arg.SavedAt,
+		arg.HiddenAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
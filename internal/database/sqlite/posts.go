@@ -0,0 +1,277 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func scanPost(row scanner) (database.Post, error) {
+	var i database.Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+	)
+	return i, err
+}
+
+const createPost = `
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+VALUES (?1, NOW(), NOW(), ?2, ?3, ?4, ?5, ?6)
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+`
+
+func (q *Queries) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	return scanPost(q.queryRow(ctx, createPost,
+		arg.ID,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+	))
+}
+
+const deletePosts = `
+DELETE FROM posts
+WHERE id IN (/*SLICE:ids*/?)
+`
+
+func (q *Queries) DeletePosts(ctx context.Context, ids []uuid.UUID) error {
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err := q.exec(ctx, expandSlice(deletePosts, "ids", len(ids)), args...)
+	return err
+}
+
+const getFollowedPostsForUser = `
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id,
+    feeds.name AS feed_name,
+    feeds.url AS feed_url
+FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = ?1
+ORDER BY posts.created_at
+`
+
+func scanFollowedPost(row scanner) (database.GetFollowedPostsForUserRow, error) {
+	var i database.GetFollowedPostsForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.FeedName,
+		&i.FeedUrl,
+	)
+	return i, err
+}
+
+func (q *Queries) GetFollowedPostsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFollowedPostsForUserRow, error) {
+	return queryAll(ctx, q, scanFollowedPost, getFollowedPostsForUser, userID)
+}
+
+const getPostByURL = `
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+WHERE url = ?1
+`
+
+func (q *Queries) GetPostByURL(ctx context.Context, url string) (database.Post, error) {
+	return scanPost(q.queryRow(ctx, getPostByURL, url))
+}
+
+const getPostFromID = `
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+WHERE id = ?1
+`
+
+func (q *Queries) GetPostFromID(ctx context.Context, id uuid.UUID) (database.Post, error) {
+	return scanPost(q.queryRow(ctx, getPostFromID, id))
+}
+
+const getPostsForUser = `
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name
+FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = ?1
+AND (?2 IS NULL OR feed_follows.folder_id = ?2)
+AND (?3 IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    INNER JOIN tags
+    ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id
+    AND tags.user_id = ?1
+    AND tags.name = ?3
+))
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.user_id = ?1
+    AND post_states.hidden_at IS NOT NULL
+)
+ORDER BY posts.created_at DESC
+LIMIT ?4
+`
+
+func scanPostForUser(row scanner) (database.GetPostsForUserRow, error) {
+	var i database.GetPostsForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.FeedName,
+	)
+	return i, err
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	return queryAll(ctx, q, scanPostForUser, getPostsForUser,
+		arg.UserID,
+		arg.FolderID,
+		arg.Tag,
+		arg.Limit,
+	)
+}
+
+// A NULL limit means no limit in Postgres; SQLite spells that -1.
+const getPrunablePostsForFeed = `
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id FROM posts
+WHERE posts.feed_id = ?1
+AND (
+    COALESCE(posts.published_at, posts.created_at) < ?2
+    OR posts.id NOT IN (
+        SELECT newest.id FROM posts AS newest
+        WHERE newest.feed_id = ?1
+        ORDER BY COALESCE(newest.published_at, newest.created_at) DESC
+        LIMIT COALESCE(?3, -1)
+    )
+)
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.saved_at IS NOT NULL
+)
+AND NOT (
+    posts.created_at >= ?4
+    AND EXISTS (
+        SELECT 1 FROM feed_follows
+        LEFT JOIN post_states
+        ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+        WHERE feed_follows.feed_id = posts.feed_id
+        AND post_states.read_at IS NULL
+    )
+)
+ORDER BY COALESCE(posts.published_at, posts.created_at) ASC
+`
+
+func (q *Queries) GetPrunablePostsForFeed(ctx context.Context, arg database.GetPrunablePostsForFeedParams) ([]database.Post, error) {
+	return queryAll(ctx, q, scanPost, getPrunablePostsForFeed,
+		arg.FeedID,
+		arg.OlderThan,
+		arg.MaxPosts,
+		arg.UnreadSince,
+	)
+}
+
+const deleteAllPosts = `
+DELETE FROM posts
+`
+
+func (q *Queries) DeleteAllPosts(ctx context.Context) error {
+	_, err := q.exec(ctx, deleteAllPosts)
+	return err
+}
+
+const getPosts = `
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+ORDER BY created_at
+`
+
+func (q *Queries) GetPosts(ctx context.Context) ([]database.Post, error) {
+	return queryAll(ctx, q, scanPost, getPosts)
+}
+
+const restorePost = `
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) RestorePost(ctx context.Context, arg database.RestorePostParams) (int64, error) {
+	result, err := q.exec(ctx, restorePost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanGetPostsForFeedForUserRow(row scanner) (database.GetPostsForFeedForUserRow, error) {
+	var i database.GetPostsForFeedForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.ReadAt,
+		&i.SavedAt,
+	)
+	return i, err
+}
+
+const getPostsForFeedForUser = `
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id,
+    post_states.read_at,
+    post_states.saved_at
+FROM posts
+LEFT JOIN post_states
+ON post_states.post_id = posts.id
+AND post_states.user_id = ?1
+WHERE posts.feed_id = ?2
+AND post_states.hidden_at IS NULL
+ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC
+`
+
+func (q *Queries) GetPostsForFeedForUser(ctx context.Context, arg database.GetPostsForFeedForUserParams) ([]database.GetPostsForFeedForUserRow, error) {
+	return queryAll(ctx, q, scanGetPostsForFeedForUserRow, getPostsForFeedForUser, arg.UserID, arg.FeedID)
+}
@@ -0,0 +1,124 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/Omorfii/aggregator/internal/storage/storagetest"
+	"github.com/google/uuid"
+)
+
+func TestGetPrunablePostsForFeed(t *testing.T) {
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Posts of the feed by title, with how long ago they were published
+	// and fetched.
+	ages := map[string]time.Duration{
+		"old":    30 * 24 * time.Hour,
+		"middle": 10 * 24 * time.Hour,
+		"recent": 2 * 24 * time.Hour,
+		"new":    time.Hour,
+	}
+
+	tests := []struct {
+		name         string
+		maxAge       time.Duration
+		maxPosts     int32
+		unreadWindow time.Duration
+		unread       []string
+		saved        []string
+		want         []string
+	}{
+		{name: "max age", maxAge: 7 * 24 * time.Hour, want: []string{"old", "middle"}},
+		{name: "max posts", maxPosts: 3, want: []string{"old"}},
+		{name: "max age or max posts", maxAge: 20 * 24 * time.Hour, maxPosts: 2, want: []string{"old", "middle"}},
+		{name: "nothing over the limits", maxAge: 60 * 24 * time.Hour, maxPosts: 10},
+		{name: "saved posts are kept", maxAge: 7 * 24 * time.Hour, saved: []string{"old"}, want: []string{"middle"}},
+		{
+			name:         "unread posts within the window are kept",
+			maxAge:       time.Hour / 2,
+			unreadWindow: 14 * 24 * time.Hour,
+			unread:       []string{"middle", "recent"},
+			want:         []string{"old", "new"},
+		},
+		{
+			name:         "unread posts past the window are pruned",
+			maxAge:       7 * 24 * time.Hour,
+			unreadWindow: 3 * 24 * time.Hour,
+			unread:       []string{"old", "middle"},
+			want:         []string{"old", "middle"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			ctx := context.Background()
+			store := storagetest.Open(t)
+
+			user, err := store.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "alice"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			feed, err := store.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "feed", Url: "https://example.com/feed", UserID: user.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID}); err != nil {
+				t.Fatal(err)
+			}
+
+			for title, age := range ages {
+
+				// CreatePost stamps created_at with the current time, so the
+				// posts are inserted directly to date them back.
+				id := uuid.New()
+				_, err := store.DB.ExecContext(ctx,
+					"INSERT INTO posts (id, created_at, updated_at, title, url, published_at, feed_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+					id, now.Add(-age), now.Add(-age), title, "https://example.com/"+title, now.Add(-age), feed.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !slices.Contains(test.unread, title) {
+					if err := store.MarkPostRead(ctx, database.MarkPostReadParams{UserID: user.ID, PostID: id}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if slices.Contains(test.saved, title) {
+					if err := store.SavePost(ctx, database.SavePostParams{UserID: user.ID, PostID: id}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			parameters := database.GetPrunablePostsForFeedParams{
+				FeedID:      feed.ID,
+				MaxPosts:    sql.NullInt32{Int32: test.maxPosts, Valid: test.maxPosts > 0},
+				UnreadSince: now.Add(-test.unreadWindow),
+			}
+			if test.maxAge > 0 {
+				parameters.OlderThan = sql.NullTime{Time: now.Add(-test.maxAge), Valid: true}
+			}
+
+			posts, err := store.GetPrunablePostsForFeed(ctx, parameters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, post := range posts {
+				got = append(got, post.Title)
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("prunable posts = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func scanSession(row scanner) (database.Session, error) {
+	var i database.Session
+	err := row.Scan(
+		&i.TokenHash,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createSession = `
+INSERT INTO sessions (token_hash, user_id, created_at, expires_at)
+VALUES (?1, ?2, ?3, ?4)
+RETURNING token_hash, user_id, created_at, expires_at
+`
+
+func (q *Queries) CreateSession(ctx context.Context, arg database.CreateSessionParams) (database.Session, error) {
+	return scanSession(q.queryRow(ctx, createSession,
+		arg.TokenHash,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	))
+}
+
+const getUserFromSession = `
+SELECT users.id, users.created_at, users.updated_at, users.name, users.password_hash, users.role FROM sessions
+INNER JOIN users ON sessions.user_id = users.id
+WHERE sessions.token_hash = ?1 AND sessions.expires_at > ?2
+`
+
+func (q *Queries) GetUserFromSession(ctx context.Context, arg database.GetUserFromSessionParams) (database.User, error) {
+	return scanUser(q.queryRow(ctx, getUserFromSession, arg.TokenHash, arg.ExpiresAt))
+}
+
+const deleteSession = `
+DELETE FROM sessions
+WHERE token_hash = ?1
+`
+
+func (q *Queries) DeleteSession(ctx context.Context, tokenHash string) error {
+	_, err := q.exec(ctx, deleteSession, tokenHash)
+	return err
+}
+
+const deleteSessionsForUser = `
+DELETE FROM sessions
+WHERE user_id = ?1
+`
+
+func (q *Queries) DeleteSessionsForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.exec(ctx, deleteSessionsForUser, userID)
+	return err
+}
+
+const deleteExpiredSessions = `
+DELETE FROM sessions
+WHERE expires_at <= ?1
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.exec(ctx, deleteExpiredSessions, expiresAt)
+	return err
+}
@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func scanTag(row scanner) (database.Tag, error) {
+	var i database.Tag
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Name,
+	)
+	return i, err
+}
+
+func scanPostTag(row scanner) (database.PostTag, error) {
+	var i database.PostTag
+	err := row.Scan(
+		&i.TagID,
+		&i.PostID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createTag = `
+INSERT INTO tags (id, created_at, updated_at, user_id, name)
+VALUES (?1, ?2, ?3, ?4, ?5)
+ON CONFLICT (user_id, name)
+DO UPDATE SET updated_at = excluded.updated_at
+RETURNING id, created_at, updated_at, user_id, name
+`
+
+func (q *Queries) CreateTag(ctx context.Context, arg database.CreateTagParams) (database.Tag, error) {
+	return scanTag(q.queryRow(ctx, createTag,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.Name,
+	))
+}
+
+const deleteTagIfUnused = `
+DELETE FROM tags
+WHERE id = ?1
+AND NOT EXISTS (
+    SELECT 1 FROM post_tags
+    WHERE post_tags.tag_id = tags.id
+)
+`
+
+func (q *Queries) DeleteTagIfUnused(ctx context.Context, id uuid.UUID) error {
+	_, err := q.exec(ctx, deleteTagIfUnused, id)
+	return err
+}
+
+const getTag = `
+SELECT id, created_at, updated_at, user_id, name FROM tags
+WHERE user_id = ?1 AND name = ?2
+`
+
+func (q *Queries) GetTag(ctx context.Context, arg database.GetTagParams) (database.Tag, error) {
+	return scanTag(q.queryRow(ctx, getTag, arg.UserID, arg.Name))
+}
+
+const getTagsForUser = `
+SELECT
+    tags.name,
+    COUNT(post_tags.post_id) AS post_count
+FROM tags
+LEFT JOIN post_tags
+ON post_tags.tag_id = tags.id
+WHERE tags.user_id = ?1
+GROUP BY tags.id, tags.name
+ORDER BY tags.name
+`
+
+func scanTagCount(row scanner) (database.GetTagsForUserRow, error) {
+	var i database.GetTagsForUserRow
+	err := row.Scan(&i.Name, &i.PostCount)
+	return i, err
+}
+
+func (q *Queries) GetTagsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetTagsForUserRow, error) {
+	return queryAll(ctx, q, scanTagCount, getTagsForUser, userID)
+}
+
+const tagPost = `
+INSERT INTO post_tags (tag_id, post_id, created_at)
+VALUES (?1, ?2, NOW())
+ON CONFLICT (tag_id, post_id) DO NOTHING
+`
+
+func (q *Queries) TagPost(ctx context.Context, arg database.TagPostParams) error {
+	_, err := q.exec(ctx, tagPost, arg.TagID, arg.PostID)
+	return err
+}
+
+const untagPost = `
+DELETE FROM post_tags
+WHERE tag_id = ?1 AND post_id = ?2
+`
+
+func (q *Queries) UntagPost(ctx context.Context, arg database.UntagPostParams) error {
+	_, err := q.exec(ctx, untagPost, arg.TagID, arg.PostID)
+	return err
+}
+
+const getTags = `
+SELECT id, created_at, updated_at, user_id, name FROM tags
+ORDER BY created_at
+`
+
+func (q *Queries) GetTags(ctx context.Context) ([]database.Tag, error) {
+	return queryAll(ctx, q, scanTag, getTags)
+}
+
+const getPostTags = `
+SELECT tag_id, post_id, created_at FROM post_tags
+`
+
+func (q *Queries) GetPostTags(ctx context.Context) ([]database.PostTag, error) {
+	return queryAll(ctx, q, scanPostTag, getPostTags)
+}
+
+const restoreTag = `
+INSERT INTO tags (id, created_at, updated_at, user_id, name)
+VALUES (?1, ?2, ?3, ?4, ?5)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) RestoreTag(ctx context.Context, arg database.RestoreTagParams) (int64, error) {
+	result, err := q.exec(ctx, restoreTag,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.Name,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restorePostTag = `
+INSERT INTO post_tags (tag_id, post_id, created_at)
+VALUES (?1, ?2, ?3)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) RestorePostTag(ctx context.Context, arg database.RestorePostTagParams) (int64, error) {
+	result, err := q.exec(ctx, restorePostTag, arg.TagID, arg.PostID, arg.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func scanUser(row scanner) (database.User, error) {
+	var i database.User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.PasswordHash,
+		&i.Role,
+	)
+	return i, err
+}
+
+const createUser = `
+INSERT INTO users (id, created_at, updated_at, name, password_hash, role)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+RETURNING id, created_at, updated_at, name, password_hash, role
+`
+
+func (q *Queries) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	return scanUser(q.queryRow(ctx, createUser,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.PasswordHash,
+		arg.Role,
+	))
+}
+
+const deleteAllUsers = `
+DELETE FROM users
+`
+
+func (q *Queries) DeleteAllUsers(ctx context.Context) error {
+	_, err := q.exec(ctx, deleteAllUsers)
+	return err
+}
+
+const getUser = `
+SELECT id, created_at, updated_at, name, password_hash, role FROM users
+WHERE name = ?1
+`
+
+func (q *Queries) GetUser(ctx context.Context, name string) (database.User, error) {
+	return scanUser(q.queryRow(ctx, getUser, name))
+}
+
+const getUserFromID = `
+SELECT id, created_at, updated_at, name, password_hash, role FROM users
+WHERE id = ?1
+`
+
+func (q *Queries) GetUserFromID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return scanUser(q.queryRow(ctx, getUserFromID, id))
+}
+
+const getUsers = `
+SELECT id, created_at, updated_at, name, password_hash, role FROM users
+`
+
+func (q *Queries) GetUsers(ctx context.Context) ([]database.User, error) {
+	return queryAll(ctx, q, scanUser, getUsers)
+}
+
+const deleteUser = `
+DELETE FROM users
+WHERE id = ?1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.exec(ctx, deleteUser, id)
+	return err
+}
+
+const restoreUser = `
+INSERT INTO users (id, created_at, updated_at, name, password_hash, role)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, arg database.RestoreUserParams) (int64, error) {
+	result, err := q.exec(ctx, restoreUser,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.PasswordHash,
+		arg.Role,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserPassword = `
+UPDATE users
+SET password_hash = ?2, updated_at = ?3
+WHERE id = ?1
+`
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	_, err := q.exec(ctx, setUserPassword, arg.ID, arg.PasswordHash, arg.UpdatedAt)
+	return err
+}
+
+const setUserRole = `
+UPDATE users
+SET role = ?2, updated_at = ?3
+WHERE id = ?1
+`
+
+func (q *Queries) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) error {
+	_, err := q.exec(ctx, setUserRole, arg.ID, arg.Role, arg.UpdatedAt)
+	return err
+}
+
+const countUsersWithRole = `
+SELECT COUNT(*) FROM users
+WHERE role = ?1
+`
+
+func (q *Queries) CountUsersWithRole(ctx context.Context, role string) (int64, error) {
+	var count int64
+	err := q.queryRow(ctx, countUsersWithRole, role).Scan(&count)
+	return count, err
+}
+
+const getUsersWithoutPassword = `
+SELECT id, created_at, updated_at, name, password_hash, role FROM users
+WHERE password_hash = ''
+ORDER BY created_at
+`
+
+func (q *Queries) GetUsersWithoutPassword(ctx context.Context) ([]database.User, error) {
+	return queryAll(ctx, q, scanUser, getUsersWithoutPassword)
+}
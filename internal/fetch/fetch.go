@@ -0,0 +1,340 @@
+// Package fetch downloads feeds for agg, with timeouts, a size limit, the
+// TLS and proxy settings from the fetch section of the config and a guard
+// against feed urls pointing at internal hosts.
+package fetch
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/config"
+	"github.com/Omorfii/aggregator/internal/metrics"
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultReadTimeout    = 30 * time.Second
+	DefaultMaxBodySize    = 10 << 20
+	DefaultMaxRedirects   = 5
+	DefaultHostInterval   = time.Second
+	DefaultHostConcurrent = 2
+	DefaultUserAgent      = "gator"
+
+	// A Retry-After further away than this is cut short, so that a
+	// misbehaving server cannot stop a feed from being fetched for good.
+	maxRetryAfter = 24 * time.Hour
+)
+
+// Client downloads feeds. It is safe for concurrent use.
+type Client struct {
+	http           *http.Client
+	limiter        *hostLimiter
+	userAgent      string
+	connectTimeout time.Duration
+	readTimeout    time.Duration
+	maxBodySize    int64
+}
+
+// RetryAfterError is returned when a server answers 429 or 503 with a
+// Retry-After header; the feed should not be fetched again before Until.
+type RetryAfterError struct {
+	Status string
+	Until  time.Time
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("server answered %v, asking to retry after %v", e.Status, e.Until.Format(time.RFC3339))
+}
+
+// New builds a Client from the fetch settings, using the defaults for the
+// ones left unset.
+func New(cfg config.FetchConfig) (*Client, error) {
+
+	c := &Client{
+		userAgent:      DefaultUserAgent,
+		connectTimeout: DefaultConnectTimeout,
+		readTimeout:    DefaultReadTimeout,
+		maxBodySize:    DefaultMaxBodySize,
+	}
+
+	var err error
+
+	if cfg.UserAgent != "" {
+		c.userAgent = cfg.UserAgent
+	}
+	if cfg.ContactURL != "" {
+		c.userAgent += " (+" + cfg.ContactURL + ")"
+	}
+
+	hostInterval := DefaultHostInterval
+	if cfg.HostInterval != "" {
+		if hostInterval, err = time.ParseDuration(cfg.HostInterval); err != nil {
+			return nil, fmt.Errorf("fetch.host_interval: %w", err)
+		}
+	}
+
+	hostConcurrency := DefaultHostConcurrent
+	if cfg.HostConcurrency > 0 {
+		hostConcurrency = cfg.HostConcurrency
+	}
+
+	c.limiter = newHostLimiter(hostInterval, hostConcurrency)
+
+	if cfg.ConnectTimeout != "" {
+		if c.connectTimeout, err = time.ParseDuration(cfg.ConnectTimeout); err != nil {
+			return nil, fmt.Errorf("fetch.connect_timeout: %w", err)
+		}
+	}
+
+	if cfg.ReadTimeout != "" {
+		if c.readTimeout, err = time.ParseDuration(cfg.ReadTimeout); err != nil {
+			return nil, fmt.Errorf("fetch.read_timeout: %w", err)
+		}
+	}
+
+	if cfg.MaxBodySize > 0 {
+		c.maxBodySize = cfg.MaxBodySize
+	}
+
+	maxRedirects := DefaultMaxRedirects
+	if cfg.MaxRedirects != nil {
+		maxRedirects = *cfg.MaxRedirects
+	}
+
+	tlsConfig := &tls.Config{}
+	if cfg.CABundle != "" {
+		if tlsConfig.RootCAs, err = loadCABundle(cfg.CABundle); err != nil {
+			return nil, err
+		}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	switch cfg.Proxy {
+	case "":
+	case "none":
+		proxy = nil
+	default:
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("fetch.proxy: %w", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	addressGuard, err := newGuard(cfg.Allow, cfg.Deny)
+	if err != nil {
+		return nil, err
+	}
+
+	// With a proxy, the dialer sees the address of the proxy, which then
+	// has to be allowed if it is on the local network; the feed's host is
+	// checked by proxyGuardTransport instead.
+	dialer := &net.Dialer{
+		Timeout:   c.connectTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   addressGuard.control,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   c.connectTimeout,
+		ResponseHeaderTimeout: c.readTimeout,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+
+	var roundTripper http.RoundTripper = &limitedTransport{next: transport, limiter: c.limiter}
+	if proxy != nil {
+		roundTripper = &proxyGuardTransport{next: roundTripper, proxy: proxy, guard: addressGuard}
+	}
+
+	c.http = &http.Client{
+		Transport: roundTripper,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+
+	return c, nil
+}
+
+// loadCABundle returns the system roots plus the PEM certificates in path,
+// so that feeds signed by a private CA work without breaking public ones.
+func loadCABundle(path string) (*x509.CertPool, error) {
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	bundle, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fetch.ca_bundle: %w", err)
+	}
+
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("fetch.ca_bundle: no PEM certificates in %v", path)
+	}
+
+	return pool, nil
+}
+
+// Get downloads feedURL and returns its decoded body. Errors say which
+// step failed: connecting, an unexpected status, a timeout or a body over
+// the size limit.
+func (c *Client) Get(ctx context.Context, feedURL string) ([]byte, error) {
+
+	// The read timeout covers the whole response, the connect timeout
+	// everything before it.
+	ctx, cancel := context.WithTimeout(ctx, c.connectTimeout+c.readTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.9, */*;q=0.1")
+	req.Header.Set("Accept-Encoding", "gzip, br")
+
+	started := time.Now()
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		metrics.Fetches.WithLabelValues(metrics.StatusError).Inc()
+		return nil, c.describe(ctx, err)
+	}
+
+	defer res.Body.Close()
+
+	metrics.Fetches.WithLabelValues(strconv.Itoa(res.StatusCode)).Inc()
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+		if until, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+			c.limiter.delay(res.Request.URL.Host, res.Status, until)
+			return nil, &RetryAfterError{Status: res.Status, Until: until}
+		}
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %v", res.Status)
+	}
+
+	if res.ContentLength > c.maxBodySize {
+		return nil, fmt.Errorf("feed is %d bytes, more than the limit of %d", res.ContentLength, c.maxBodySize)
+	}
+
+	downloaded := &countingReader{reader: res.Body}
+
+	body, err := decode(downloaded, res.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Reading one byte past the limit tells a feed of exactly the limit
+	// from a bigger one, including bodies that only grow once decoded.
+	content, err := io.ReadAll(io.LimitReader(body, c.maxBodySize+1))
+	metrics.DownloadedBytes.Add(float64(downloaded.count))
+	metrics.FetchDuration.Observe(time.Since(started).Seconds())
+	if err != nil {
+		return nil, c.describe(ctx, err)
+	}
+
+	if int64(len(content)) > c.maxBodySize {
+		return nil, fmt.Errorf("feed is larger than the limit of %d bytes", c.maxBodySize)
+	}
+
+	return content, nil
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as
+// an HTTP date.
+func retryAfter(header string) (time.Time, bool) {
+
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return time.Time{}, false
+	}
+
+	var wait time.Duration
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		wait = time.Until(date)
+	} else {
+		return time.Time{}, false
+	}
+
+	return time.Now().Add(min(max(wait, 0), maxRetryAfter)), true
+}
+
+// decode undoes the Content-Encoding of a response.
+func decode(body io.Reader, encoding string) (io.Reader, error) {
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("decoding gzip body: %w", err)
+		}
+		return reader, nil
+	case "br":
+		return brotli.NewReader(body), nil
+	}
+
+	return nil, fmt.Errorf("unsupported content encoding %v", encoding)
+}
+
+// describe rewords the timeout errors of the http package, which only
+// name the deadline, to say which limit was hit.
+func (c *Client) describe(ctx context.Context, err error) error {
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %v: %w", c.connectTimeout+c.readTimeout, err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return fmt.Errorf("connection timed out after %v: %w", c.connectTimeout, err)
+		}
+		return fmt.Errorf("no response within %v: %w", c.readTimeout, err)
+	}
+
+	return err
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,237 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/config"
+)
+
+func TestRetryAfter(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		header string
+		ok     bool
+		wait   time.Duration
+	}{
+		{name: "empty", header: ""},
+		{name: "garbage", header: "soon"},
+		{name: "seconds", header: "120", ok: true, wait: 120 * time.Second},
+		{name: "seconds with spaces", header: " 30 ", ok: true, wait: 30 * time.Second},
+		{name: "zero", header: "0", ok: true},
+		{name: "negative", header: "-5", ok: true},
+		{name: "beyond the cap", header: "604800", ok: true, wait: maxRetryAfter},
+		{name: "date", header: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), ok: true, wait: time.Hour},
+		{name: "date in the past", header: "Mon, 02 Jan 2006 15:04:05 GMT", ok: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			until, ok := retryAfter(test.header)
+			if ok != test.ok {
+				t.Fatalf("retryAfter(%q) ok = %v, want %v", test.header, ok, test.ok)
+			}
+			if !ok {
+				return
+			}
+
+			// HTTP dates only have whole seconds.
+			if wait := time.Until(until); wait > test.wait+time.Second || wait < test.wait-2*time.Second {
+				t.Errorf("retryAfter(%q) waits %v, want %v", test.header, wait, test.wait)
+			}
+		})
+	}
+}
+
+// redirectServer redirects /hops/<n> to /hops/<n-1> until it reaches
+// /hops/0, which serves a feed.
+func redirectServer(t *testing.T) *httptest.Server {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hops, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hops/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if hops > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/hops/%d", hops-1), http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "<rss></rss>")
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestClientRedirectLimit(t *testing.T) {
+
+	server := redirectServer(t)
+
+	maxRedirects := func(n int) *int { return &n }
+
+	tests := []struct {
+		name         string
+		maxRedirects *int
+		hops         int
+		ok           bool
+	}{
+		{name: "no redirect", maxRedirects: maxRedirects(0), hops: 0, ok: true},
+		{name: "redirects not followed", maxRedirects: maxRedirects(0), hops: 1},
+		{name: "at the limit", maxRedirects: maxRedirects(2), hops: 2, ok: true},
+		{name: "over the limit", maxRedirects: maxRedirects(2), hops: 3},
+		{name: "default limit", hops: DefaultMaxRedirects, ok: true},
+		{name: "over the default limit", hops: DefaultMaxRedirects + 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			client, err := New(config.FetchConfig{
+				MaxRedirects: test.maxRedirects,
+				Allow:        []string{"127.0.0.1"},
+				HostInterval: "1ms",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = client.Get(context.Background(), fmt.Sprintf("%v/hops/%d", server.URL, test.hops))
+			if ok := err == nil; ok != test.ok {
+				t.Errorf("following %d redirects: got %v, want ok %v", test.hops, err, test.ok)
+			}
+		})
+	}
+}
+
+func TestClientMaxBodySize(t *testing.T) {
+
+	// /plain/<n> serves n bytes, /gzip/<n> serves n bytes compressed.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding, size, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		content := bytes.Repeat([]byte("a"), n)
+		if encoding == "gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write(content)
+			gz.Close()
+			return
+		}
+		w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{path: "/plain/1024", ok: true},
+		{path: "/plain/1025"},
+		{path: "/gzip/1024", ok: true},
+		{path: "/gzip/4096"},
+	}
+
+	client, err := New(config.FetchConfig{
+		MaxBodySize:  1024,
+		Allow:        []string{"127.0.0.1"},
+		HostInterval: "1ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			content, err := client.Get(context.Background(), server.URL+test.path)
+			if ok := err == nil; ok != test.ok {
+				t.Errorf("fetching %v with a limit of 1024 bytes: got %d bytes and %v, want ok %v", test.path, len(content), err, test.ok)
+			}
+		})
+	}
+}
+
+func TestClientBlocksLoopbackRedirect(t *testing.T) {
+
+	server := redirectServer(t)
+
+	client, err := New(config.FetchConfig{HostInterval: "1ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get(context.Background(), server.URL+"/hops/1")
+	if err == nil || !strings.Contains(err.Error(), "loopback") {
+		t.Errorf("fetching a loopback feed got %v, want it blocked", err)
+	}
+}
+
+func TestClientChecksHostsBehindProxy(t *testing.T) {
+
+	// The proxy answers every request itself, redirecting /redirect to an
+	// internal address, and records which urls it was asked for.
+	var requested []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.String())
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://10.1.2.3/feed", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "<rss></rss>")
+	}))
+	t.Cleanup(proxy.Close)
+
+	tests := []struct {
+		name      string
+		url       string
+		ok        bool
+		requested []string
+	}{
+		{name: "public host", url: "http://93.184.216.34/feed", ok: true, requested: []string{"http://93.184.216.34/feed"}},
+		{name: "private host", url: "http://10.1.2.3/feed"},
+		{name: "carrier-grade NAT host", url: "http://100.64.1.1/feed"},
+		{name: "redirect to a private host", url: "http://93.184.216.34/redirect", requested: []string{"http://93.184.216.34/redirect"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// The proxy itself is on loopback, so it has to be allowed.
+			client, err := New(config.FetchConfig{
+				Proxy:        proxy.URL,
+				Allow:        []string{"127.0.0.1"},
+				HostInterval: "1ms",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			requested = nil
+
+			_, err = client.Get(context.Background(), test.url)
+			if ok := err == nil; ok != test.ok {
+				t.Errorf("fetching %v through a proxy: got %v, want ok %v", test.url, err, test.ok)
+			}
+			if !test.ok && (err == nil || !strings.Contains(err.Error(), "blocked")) {
+				t.Errorf("fetching %v through a proxy: got %v, want it blocked", test.url, err)
+			}
+			if fmt.Sprint(requested) != fmt.Sprint(test.requested) {
+				t.Errorf("proxy was asked for %v, want %v", requested, test.requested)
+			}
+		})
+	}
+}
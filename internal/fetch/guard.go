@@ -0,0 +1,181 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+
+	"github.com/Omorfii/aggregator/internal/config"
+)
+
+// guard keeps feed urls from reaching the host gator runs on or its
+// network. It checks the address of every connection after the host name
+// was resolved, so neither a redirect nor a DNS name pointing inside can
+// get around it.
+type guard struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// blockedRanges are internal ranges that netip has no predicate for.
+var blockedRanges = []struct {
+	prefix netip.Prefix
+	reason string
+}{
+	{netip.MustParsePrefix("0.0.0.0/8"), "a \"this network\" address"},
+	{netip.MustParsePrefix("100.64.0.0/10"), "a shared (carrier-grade NAT) address"},
+	{netip.MustParsePrefix("192.0.0.0/24"), "an IETF protocol assignment address"},
+	{netip.MustParsePrefix("198.18.0.0/15"), "a benchmarking address"},
+	{netip.MustParsePrefix("240.0.0.0/4"), "a reserved or broadcast address"},
+	{netip.MustParsePrefix("64:ff9b::/96"), "a NAT64 address"},
+}
+
+// sixToFour holds 6to4 addresses, which carry an IPv4 address in their
+// second to sixth bytes and reach it through a relay.
+var sixToFour = netip.MustParsePrefix("2002::/16")
+
+func newGuard(allow []string, deny []string) (*guard, error) {
+
+	g := &guard{}
+
+	for _, addressRange := range allow {
+		prefix, err := config.ParseAddressRange(addressRange)
+		if err != nil {
+			return nil, fmt.Errorf("fetch.allow: %w", err)
+		}
+		g.allow = append(g.allow, prefix)
+	}
+
+	for _, addressRange := range deny {
+		prefix, err := config.ParseAddressRange(addressRange)
+		if err != nil {
+			return nil, fmt.Errorf("fetch.deny: %w", err)
+		}
+		g.deny = append(g.deny, prefix)
+	}
+
+	return g, nil
+}
+
+// check returns why address may not be connected to, or nil if it may.
+// The allow list wins over everything else.
+func (g *guard) check(address netip.Addr) error {
+
+	address = address.Unmap()
+
+	for _, prefix := range g.allow {
+		if prefix.Contains(address) {
+			return nil
+		}
+	}
+
+	var reason string
+
+	switch {
+	case address.IsLoopback():
+		reason = "a loopback address"
+	case address.IsPrivate():
+		reason = "a private address"
+	case address.IsLinkLocalUnicast(), address.IsLinkLocalMulticast():
+		reason = "a link-local address"
+	case address.IsUnspecified():
+		reason = "an unspecified address"
+	case address.IsMulticast():
+		reason = "a multicast address"
+	}
+
+	for _, blocked := range blockedRanges {
+		if reason == "" && blocked.prefix.Contains(address) {
+			reason = blocked.reason
+		}
+	}
+
+	for _, prefix := range g.deny {
+		if reason == "" && prefix.Contains(address) {
+			reason = "in the denied range " + prefix.String()
+		}
+	}
+
+	if reason == "" && sixToFour.Contains(address) {
+		bytes := address.As16()
+		embedded := netip.AddrFrom4([4]byte{bytes[2], bytes[3], bytes[4], bytes[5]})
+		if err := g.check(embedded); err != nil {
+			return fmt.Errorf("%v is a 6to4 address: %w", address, err)
+		}
+	}
+
+	if reason == "" {
+		return nil
+	}
+
+	return fmt.Errorf("%v is %v, add it to fetch.allow to fetch from it", address, reason)
+}
+
+// control is the net.Dialer Control function applying the guard.
+func (g *guard) control(network string, address string, _ syscall.RawConn) error {
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("unexpected dial address %v: %w", address, err)
+	}
+
+	if err := g.check(addrPort.Addr()); err != nil {
+		return fmt.Errorf("blocked: %w", err)
+	}
+
+	return nil
+}
+
+// checkHost resolves host and checks every address it has.
+func (g *guard) checkHost(ctx context.Context, host string) error {
+
+	if address, err := netip.ParseAddr(host); err == nil {
+		return g.check(address)
+	}
+
+	addresses, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		if err := g.check(address); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// proxyGuardTransport checks the host of every request sent through a
+// proxy, redirects included. The dialer only sees the connection to the
+// proxy, which resolves and connects to the feed's host itself.
+//
+// The proxy looks the host up again, so a name whose DNS answer changes
+// between the check and the proxy's lookup can still lead inside; the
+// proxy's own rules have to block internal addresses to close that gap.
+type proxyGuardTransport struct {
+	next  http.RoundTripper
+	proxy func(*http.Request) (*url.URL, error)
+	guard *guard
+}
+
+func (t *proxyGuardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	proxyURL, err := t.proxy(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if proxyURL != nil {
+		if err := t.guard.checkHost(req.Context(), req.URL.Hostname()); err != nil {
+			return nil, fmt.Errorf("blocked: %w", err)
+		}
+	}
+
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,119 @@
+package fetch
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+)
+
+func TestGuardCheck(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		address string
+		allow   []string
+		deny    []string
+		blocked bool
+	}{
+		{name: "public IPv4", address: "93.184.216.34"},
+		{name: "public IPv6", address: "2606:2800:220:1:248:1893:25c8:1946"},
+		{name: "loopback", address: "127.0.0.1", blocked: true},
+		{name: "loopback range", address: "127.8.9.10", blocked: true},
+		{name: "IPv6 loopback", address: "::1", blocked: true},
+		{name: "private 10/8", address: "10.1.2.3", blocked: true},
+		{name: "private 172.16/12", address: "172.31.255.1", blocked: true},
+		{name: "just outside 172.16/12", address: "172.32.0.1"},
+		{name: "private 192.168/16", address: "192.168.1.1", blocked: true},
+		{name: "IPv6 unique local", address: "fd00::1", blocked: true},
+		{name: "link-local", address: "169.254.169.254", blocked: true},
+		{name: "IPv6 link-local", address: "fe80::1", blocked: true},
+		{name: "unspecified", address: "0.0.0.0", blocked: true},
+		{name: "IPv6 unspecified", address: "::", blocked: true},
+		{name: "multicast", address: "224.0.0.1", blocked: true},
+		{name: "IPv4-mapped loopback", address: "::ffff:127.0.0.1", blocked: true},
+		{name: "carrier-grade NAT", address: "100.64.0.1", blocked: true},
+		{name: "carrier-grade NAT end", address: "100.127.255.254", blocked: true},
+		{name: "just outside carrier-grade NAT", address: "100.128.0.1"},
+		{name: "NAT64", address: "64:ff9b::a01:203", blocked: true},
+		{name: "this network", address: "0.1.2.3", blocked: true},
+		{name: "IETF protocol assignments", address: "192.0.0.8", blocked: true},
+		{name: "benchmarking", address: "198.19.255.1", blocked: true},
+		{name: "just outside benchmarking", address: "198.20.0.1"},
+		{name: "reserved", address: "240.0.0.1", blocked: true},
+		{name: "broadcast", address: "255.255.255.255", blocked: true},
+		{name: "6to4 of loopback", address: "2002:7f00:1::1", blocked: true},
+		{name: "6to4 of private", address: "2002:c0a8:101::", blocked: true},
+		{name: "6to4 of public", address: "2002:5db8:d822::1"},
+		{name: "allowed 6to4 of private", address: "2002:c0a8:101::", allow: []string{"192.168.1.0/24"}},
+		{name: "allowed carrier-grade NAT", address: "100.64.0.1", allow: []string{"100.64.0.0/10"}},
+		{name: "allowed address", address: "127.0.0.1", allow: []string{"127.0.0.1"}},
+		{name: "allowed range", address: "10.1.2.3", allow: []string{"10.1.0.0/16"}},
+		{name: "outside allowed range", address: "10.2.0.1", allow: []string{"10.1.0.0/16"}, blocked: true},
+		{name: "allowed mapped address", address: "::ffff:192.168.1.1", allow: []string{"192.168.1.0/24"}},
+		{name: "denied range", address: "203.0.113.7", deny: []string{"203.0.113.0/24"}, blocked: true},
+		{name: "outside denied range", address: "203.0.114.7", deny: []string{"203.0.113.0/24"}},
+		{name: "allow wins over deny", address: "203.0.113.7", allow: []string{"203.0.113.7"}, deny: []string{"203.0.113.0/24"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			g, err := newGuard(test.allow, test.deny)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = g.check(netip.MustParseAddr(test.address))
+			if blocked := err != nil; blocked != test.blocked {
+				t.Errorf("check(%v) = %v, want blocked %v", test.address, err, test.blocked)
+			}
+		})
+	}
+}
+
+func TestNewGuardRejectsBadRanges(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+	}{
+		{name: "bad allow", allow: []string{"localhost"}},
+		{name: "bad deny", deny: []string{"10.0.0.0/33"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := newGuard(test.allow, test.deny); err == nil {
+				t.Errorf("newGuard(%v, %v) succeeded, want an error", test.allow, test.deny)
+			}
+		})
+	}
+}
+
+func TestGuardCheckHost(t *testing.T) {
+
+	tests := []struct {
+		host    string
+		blocked bool
+	}{
+		{host: "93.184.216.34"},
+		{host: "10.1.2.3", blocked: true},
+		{host: "::1", blocked: true},
+		{host: "localhost", blocked: true},
+	}
+
+	g, err := newGuard(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.host, func(t *testing.T) {
+			err := g.checkHost(context.Background(), test.host)
+			if blocked := err != nil; blocked != test.blocked {
+				t.Errorf("checkHost(%v) = %v, want blocked %v", test.host, err, test.blocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter spaces out requests to the same host and caps how many run
+// at once, so that dozens of feeds on one site are not fetched in a burst.
+type hostLimiter struct {
+	interval    time.Duration
+	concurrency int
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+type hostSlots struct {
+	running chan struct{}
+	next    time.Time
+
+	// Set when the host answered with Retry-After.
+	retryUntil  time.Time
+	retryStatus string
+}
+
+func newHostLimiter(interval time.Duration, concurrency int) *hostLimiter {
+	return &hostLimiter{
+		interval:    interval,
+		concurrency: concurrency,
+		hosts:       make(map[string]*hostSlots),
+	}
+}
+
+func (l *hostLimiter) slots(host string) *hostSlots {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	host = strings.ToLower(host)
+
+	slots, exists := l.hosts[host]
+	if !exists {
+		slots = &hostSlots{running: make(chan struct{}, l.concurrency)}
+		l.hosts[host] = slots
+	}
+
+	return slots
+}
+
+// acquire waits for a free slot on host and for its turn to start, and
+// returns the function giving the slot back. While the host wants to be
+// left alone after a Retry-After, it fails right away with a
+// RetryAfterError instead.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+
+	slots := l.slots(host)
+
+	l.mu.Lock()
+	retryUntil, retryStatus := slots.retryUntil, slots.retryStatus
+	l.mu.Unlock()
+
+	if time.Now().Before(retryUntil) {
+		return nil, &RetryAfterError{Status: retryStatus, Until: retryUntil}
+	}
+
+	select {
+	case slots.running <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	release := func() { <-slots.running }
+
+	l.mu.Lock()
+	start := time.Now()
+	if slots.next.After(start) {
+		start = slots.next
+	}
+	slots.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+// delay holds off every request to host until until, after it answered
+// with status and a Retry-After.
+func (l *hostLimiter) delay(host string, status string, until time.Time) {
+
+	slots := l.slots(host)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until.After(slots.retryUntil) {
+		slots.retryUntil = until
+		slots.retryStatus = status
+	}
+}
+
+// limitedTransport applies a hostLimiter to every request, redirects
+// included, holding the slot until the response body is closed.
+type limitedTransport struct {
+	next    http.RoundTripper
+	limiter *hostLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	release, err := t.limiter.acquire(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	res.Body = &releasingBody{ReadCloser: res.Body, release: release}
+
+	return res, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,114 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterSpacesRequests(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		hosts    []string
+		minimum  time.Duration
+	}{
+		{name: "same host", interval: 50 * time.Millisecond, hosts: []string{"a.example", "a.example", "a.example"}, minimum: 100 * time.Millisecond},
+		{name: "host names ignore case", interval: 50 * time.Millisecond, hosts: []string{"a.example", "A.EXAMPLE"}, minimum: 50 * time.Millisecond},
+		{name: "different hosts", interval: time.Second, hosts: []string{"a.example", "b.example", "c.example"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			limiter := newHostLimiter(test.interval, len(test.hosts))
+
+			started := time.Now()
+			for _, host := range test.hosts {
+				release, err := limiter.acquire(context.Background(), host)
+				if err != nil {
+					t.Fatal(err)
+				}
+				release()
+			}
+			elapsed := time.Since(started)
+
+			if elapsed < test.minimum {
+				t.Errorf("requests took %v, want at least %v", elapsed, test.minimum)
+			}
+			if test.minimum == 0 && elapsed >= test.interval {
+				t.Errorf("requests to different hosts took %v, want no wait", elapsed)
+			}
+		})
+	}
+}
+
+func TestHostLimiterCapsConcurrency(t *testing.T) {
+
+	limiter := newHostLimiter(0, 2)
+
+	var releases []func()
+	for range 2 {
+		release, err := limiter.acquire(context.Background(), "a.example")
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases = append(releases, release)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := limiter.acquire(ctx, "a.example"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("third request got %v, want it to wait for a slot", err)
+	}
+
+	if release, err := limiter.acquire(context.Background(), "b.example"); err != nil {
+		t.Fatalf("request to another host got %v", err)
+	} else {
+		release()
+	}
+
+	releases[0]()
+
+	release, err := limiter.acquire(context.Background(), "a.example")
+	if err != nil {
+		t.Fatalf("request after a release got %v", err)
+	}
+	release()
+	releases[1]()
+}
+
+func TestHostLimiterDelay(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		until   time.Duration
+		host    string
+		delayed bool
+	}{
+		{name: "delayed host", until: time.Hour, host: "a.example", delayed: true},
+		{name: "other host", until: time.Hour, host: "b.example"},
+		{name: "delay over", until: -time.Second, host: "a.example"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			limiter := newHostLimiter(0, 1)
+			limiter.delay("a.example", "429 Too Many Requests", time.Now().Add(test.until))
+
+			release, err := limiter.acquire(context.Background(), test.host)
+
+			var retryErr *RetryAfterError
+			if delayed := errors.As(err, &retryErr); delayed != test.delayed {
+				t.Fatalf("acquire(%v) = %v, want delayed %v", test.host, err, test.delayed)
+			}
+			if !test.delayed {
+				release()
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package metrics holds the Prometheus metrics of the aggregator, served on
+// /metrics by "gator agg --listen".
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gator"
+
+// StatusError is the status of a fetch that got no HTTP response at all.
+const StatusError = "error"
+
+var (
+	Fetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fetches_total",
+		Help:      "Feed fetches by HTTP status code, or \"error\" when no response came back.",
+	}, []string{"status"})
+
+	FetchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "fetch_duration_seconds",
+		Help:      "Time taken to download a feed.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	})
+
+	DownloadedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "downloaded_bytes_total",
+		Help:      "Bytes of feed bodies downloaded.",
+	})
+
+	ParseErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "parse_errors_total",
+		Help:      "Downloaded feeds that could not be parsed.",
+	})
+
+	NewPosts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "new_posts_total",
+		Help:      "Posts created, by feed url.",
+	}, []string{"feed"})
+)
+
+var queueLagDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "queue_lag_seconds"),
+	"How long the feed fetched next has waited since it was last fetched, or added if it never was.",
+	nil, nil,
+)
+
+const queueLagTimeout = 2 * time.Second
+
+// queueLag asks the database for the queue lag each time metrics are
+// scraped, so the value is never stale.
+type queueLag struct {
+	lag func(context.Context) (time.Duration, error)
+}
+
+func (q queueLag) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueLagDesc
+}
+
+func (q queueLag) Collect(ch chan<- prometheus.Metric) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), queueLagTimeout)
+	defer cancel()
+
+	lag, err := q.lag(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(queueLagDesc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(queueLagDesc, prometheus.GaugeValue, lag.Seconds())
+}
+
+// RegisterQueueLag adds the queue lag gauge, computed by lag.
+func RegisterQueueLag(lag func(context.Context) (time.Duration, error)) error {
+	return prometheus.Register(queueLag{lag: lag})
+}
+
+// Register adds /metrics to mux.
+func Register(mux *http.ServeMux) {
+	mux.Handle("GET /metrics", promhttp.Handler())
+}
@@ -0,0 +1,140 @@
+// Package storage opens the database named by the configured db_url and
+// pairs it with the matching query implementation and migrations.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/Omorfii/aggregator/internal/database/sqlite"
+	"github.com/Omorfii/aggregator/sql/schema"
+	sqliteschema "github.com/Omorfii/aggregator/sql/sqlite/schema"
+	_ "github.com/lib/pq"
+	"github.com/pressly/goose/v3"
+)
+
+// Store is a database connection together with the queries and
+// migrations for its backend.
+type Store struct {
+	database.Querier
+	DB         *sql.DB
+	Dialect    goose.Dialect
+	migrations fs.FS
+	newQuerier func(database.DBTX) database.Querier
+}
+
+// Open connects to dbURL. URLs starting with sqlite: name a local SQLite
+// file, e.g. sqlite://~/.gator.db; anything else is handed to Postgres.
+func Open(dbURL string) (*Store, error) {
+
+	if strings.HasPrefix(dbURL, "sqlite:") {
+
+		path, err := sqlitePath(dbURL)
+		if err != nil {
+			return nil, err
+		}
+
+		// The default config points at a file under ~/.gator, which may not
+		// exist yet on a first run.
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+
+		db, err := sqlite.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Store{
+			Querier:    sqlite.New(db),
+			DB:         db,
+			Dialect:    goose.DialectSQLite3,
+			migrations: sqliteschema.FS,
+			newQuerier: func(db database.DBTX) database.Querier { return sqlite.New(db) },
+		}, nil
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		Querier:    database.New(db),
+		DB:         db,
+		Dialect:    goose.DialectPostgres,
+		migrations: schema.FS,
+		newQuerier: func(db database.DBTX) database.Querier { return database.New(db) },
+	}, nil
+}
+
+// OpenExisting is Open for callers that must not create anything, like
+// shell completion: a SQLite file that does not exist yet is an error
+// instead of being created.
+func OpenExisting(dbURL string) (*Store, error) {
+
+	if strings.HasPrefix(dbURL, "sqlite:") {
+
+		path, err := sqlitePath(dbURL)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return Open(dbURL)
+}
+
+func sqlitePath(dbURL string) (string, error) {
+
+	path := strings.TrimPrefix(strings.TrimPrefix(dbURL, "sqlite:"), "//")
+	if path == "" {
+		return "", fmt.Errorf("no database file in db_url %v", dbURL)
+	}
+
+	if strings.HasPrefix(path, "~/") {
+		homePath, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(homePath, path[2:])
+	}
+
+	return path, nil
+}
+
+// MigrationProvider returns a goose provider for the backend's embedded
+// migrations.
+func (s *Store) MigrationProvider() (*goose.Provider, error) {
+	return goose.NewProvider(s.Dialect, s.DB, s.migrations)
+}
+
+// InTx runs fn with queries bound to a single transaction, which is
+// committed if fn returns nil and rolled back otherwise.
+func (s *Store) InTx(ctx context.Context, fn func(database.Querier) error) error {
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.newQuerier(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) Close() error {
+	return s.DB.Close()
+}
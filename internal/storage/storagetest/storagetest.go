@@ -0,0 +1,33 @@
+// Package storagetest provides databases for tests.
+package storagetest
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Omorfii/aggregator/internal/storage"
+)
+
+// Open returns a migrated SQLite database in a temporary directory, closed
+// when the test ends.
+func Open(t testing.TB) *storage.Store {
+
+	t.Helper()
+
+	store, err := storage.Open("sqlite://" + filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	provider, err := store.MigrationProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	breakTags = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/h[1-6]|/li|/blockquote|/pre)\s*/?>`)
+	itemTags  = regexp.MustCompile(`(?i)<\s*li[^>]*>`)
+	anyTag    = regexp.MustCompile(`<[^>]*>`)
+	blankRuns = regexp.MustCompile(`\n{3,}`)
+	spaceRuns = regexp.MustCompile(`[ \t]+`)
+)
+
+// htmlToText turns a post description into plain text for the terminal:
+// block tags become line breaks, list items get a bullet and every other
+// tag is dropped.
+func htmlToText(description string) string {
+
+	text := breakTags.ReplaceAllString(description, "\n")
+	text = itemTags.ReplaceAllString(text, "\n• ")
+	text = anyTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRuns.ReplaceAllString(line, " "))
+	}
+
+	text = strings.Join(lines, "\n")
+	text = blankRuns.ReplaceAllString(text, "\n\n")
+
+	return strings.TrimSpace(text)
+}
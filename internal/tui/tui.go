@@ -0,0 +1,646 @@
+// Package tui is the full-screen reader started by "gator tui".
+package tui
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
+)
+
+type focus int
+
+const (
+	focusFeeds focus = iota
+	focusPosts
+	focusPost
+)
+
+type feedsLoadedMsg struct {
+	feeds []database.GetFeedsWithUnreadCountForUserRow
+	err   error
+}
+
+type postsLoadedMsg struct {
+	feedID uuid.UUID
+	posts  []database.GetPostsForFeedForUserRow
+	err    error
+}
+
+type refreshMsg time.Time
+
+type statusMsg string
+
+type errMsg struct{ err error }
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	dimStyle      = lipgloss.NewStyle().Faint(true)
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, true, false, false).PaddingRight(1)
+)
+
+const helpText = "tab switch  enter open  r read/unread  s save  o browser  n next unread  g refresh  q back/quit"
+
+type model struct {
+	q       database.Querier
+	user    database.User
+	refresh time.Duration
+
+	width  int
+	height int
+
+	feeds      []database.GetFeedsWithUnreadCountForUserRow
+	feedCursor int
+
+	posts      []database.GetPostsForFeedForUserRow
+	postsFeed  uuid.UUID
+	postCursor int
+	postScroll int
+
+	focus focus
+
+	// openNextUnread is set while the posts of the next feed with unread
+	// posts load, to open its first unread post once they arrive.
+	openNextUnread bool
+
+	status string
+}
+
+// Run shows the reader for user until they quit. The feed and post lists
+// are reloaded every refresh, so posts fetched by an agg process running
+// elsewhere show up on their own.
+func Run(q database.Querier, user database.User, refresh time.Duration) error {
+
+	m := model{
+		q:       q,
+		user:    user,
+		refresh: refresh,
+	}
+
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+
+	return err
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(m.loadFeeds(), m.tick())
+}
+
+func (m model) tick() tea.Cmd {
+	return tea.Tick(m.refresh, func(t time.Time) tea.Msg {
+		return refreshMsg(t)
+	})
+}
+
+func (m model) loadFeeds() tea.Cmd {
+
+	q, userID := m.q, m.user.ID
+
+	return func() tea.Msg {
+		feeds, err := q.GetFeedsWithUnreadCountForUser(context.Background(), userID)
+		return feedsLoadedMsg{feeds: feeds, err: err}
+	}
+}
+
+func (m model) loadPosts(feedID uuid.UUID) tea.Cmd {
+
+	q := m.q
+	parameters := database.GetPostsForFeedForUserParams{
+		UserID: m.user.ID,
+		FeedID: feedID,
+	}
+
+	return func() tea.Msg {
+		posts, err := q.GetPostsForFeedForUser(context.Background(), parameters)
+		return postsLoadedMsg{feedID: feedID, posts: posts, err: err}
+	}
+}
+
+func (m model) selectedFeed() (database.GetFeedsWithUnreadCountForUserRow, bool) {
+
+	if m.feedCursor < 0 || m.feedCursor >= len(m.feeds) {
+		return database.GetFeedsWithUnreadCountForUserRow{}, false
+	}
+
+	return m.feeds[m.feedCursor], true
+}
+
+func (m model) selectedPost() (database.GetPostsForFeedForUserRow, bool) {
+
+	if m.postCursor < 0 || m.postCursor >= len(m.posts) {
+		return database.GetPostsForFeedForUserRow{}, false
+	}
+
+	return m.posts[m.postCursor], true
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case refreshMsg:
+		cmds := []tea.Cmd{m.loadFeeds(), m.tick()}
+		if feed, ok := m.selectedFeed(); ok {
+			cmds = append(cmds, m.loadPosts(feed.ID))
+		}
+		return m, tea.Batch(cmds...)
+
+	case feedsLoadedMsg:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+			return m, nil
+		}
+
+		// Keep the same feed selected when the list changes under it.
+		selected, hadSelection := m.selectedFeed()
+		m.feeds = msg.feeds
+		m.feedCursor = 0
+		if hadSelection {
+			for i, feed := range m.feeds {
+				if feed.ID == selected.ID {
+					m.feedCursor = i
+				}
+			}
+		}
+
+		if feed, ok := m.selectedFeed(); ok && feed.ID != m.postsFeed {
+			return m, m.loadPosts(feed.ID)
+		}
+
+	case postsLoadedMsg:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+			return m, nil
+		}
+
+		if feed, ok := m.selectedFeed(); !ok || feed.ID != msg.feedID {
+			return m, nil
+		}
+
+		selected, hadSelection := m.selectedPost()
+		sameFeed := m.postsFeed == msg.feedID
+		m.posts = msg.posts
+		m.postsFeed = msg.feedID
+
+		if !sameFeed {
+			m.postCursor = 0
+			m.postScroll = 0
+		} else if hadSelection {
+			for i, post := range m.posts {
+				if post.ID == selected.ID {
+					m.postCursor = i
+				}
+			}
+		}
+		m.postCursor = min(m.postCursor, max(len(m.posts)-1, 0))
+
+		if m.openNextUnread {
+			m.openNextUnread = false
+			for i, post := range m.posts {
+				if !post.ReadAt.Valid {
+					m.postCursor = i
+					return m.openPost()
+				}
+			}
+		}
+
+	case statusMsg:
+		m.status = string(msg)
+
+	case errMsg:
+		m.status = msg.err.Error()
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+
+	m.status = ""
+
+	switch msg.String() {
+
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "q", "esc":
+		switch m.focus {
+		case focusPost:
+			m.focus = focusPosts
+		case focusPosts:
+			m.focus = focusFeeds
+		default:
+			return m, tea.Quit
+		}
+
+	case "tab":
+		switch m.focus {
+		case focusFeeds:
+			m.focus = focusPosts
+		case focusPosts:
+			m.focus = focusFeeds
+		}
+
+	case "left", "h":
+		if m.focus == focusPosts {
+			m.focus = focusFeeds
+		}
+
+	case "right", "l":
+		if m.focus == focusFeeds {
+			m.focus = focusPosts
+		}
+
+	case "down", "j":
+		return m.move(1)
+
+	case "up", "k":
+		return m.move(-1)
+
+	case "pgdown", " ":
+		return m.move(m.pageSize())
+
+	case "pgup", "b":
+		return m.move(-m.pageSize())
+
+	case "enter":
+		switch m.focus {
+		case focusFeeds:
+			m.focus = focusPosts
+		case focusPosts:
+			return m.openPost()
+		}
+
+	case "r":
+		return m.toggleRead()
+
+	case "s":
+		return m.toggleSaved()
+
+	case "o":
+		if post, ok := m.selectedPost(); ok && m.focus != focusFeeds {
+			return m, openInBrowser(post.Url)
+		}
+
+	case "n":
+		return m.nextUnread()
+
+	case "g":
+		return m, m.refreshNow()
+	}
+
+	return m, nil
+}
+
+func (m model) refreshNow() tea.Cmd {
+
+	cmds := []tea.Cmd{m.loadFeeds()}
+	if feed, ok := m.selectedFeed(); ok {
+		cmds = append(cmds, m.loadPosts(feed.ID))
+	}
+
+	return tea.Batch(cmds...)
+}
+
+func (m model) pageSize() int {
+	return max(m.height-4, 1)
+}
+
+func (m model) move(delta int) (tea.Model, tea.Cmd) {
+
+	switch m.focus {
+
+	case focusFeeds:
+		if len(m.feeds) == 0 {
+			return m, nil
+		}
+		m.feedCursor = clamp(m.feedCursor+delta, 0, len(m.feeds)-1)
+		if feed, ok := m.selectedFeed(); ok && feed.ID != m.postsFeed {
+			return m, m.loadPosts(feed.ID)
+		}
+
+	case focusPosts:
+		if len(m.posts) == 0 {
+			return m, nil
+		}
+		m.postCursor = clamp(m.postCursor+delta, 0, len(m.posts)-1)
+
+	case focusPost:
+		m.postScroll = clamp(m.postScroll+delta, 0, max(len(m.postLines())-m.pageSize(), 0))
+	}
+
+	return m, nil
+}
+
+// openPost shows the selected post and marks it read.
+func (m model) openPost() (tea.Model, tea.Cmd) {
+
+	post, ok := m.selectedPost()
+	if !ok {
+		return m, nil
+	}
+
+	m.focus = focusPost
+	m.postScroll = 0
+
+	if post.ReadAt.Valid {
+		return m, nil
+	}
+
+	return m.setRead(post, true)
+}
+
+func (m model) toggleRead() (tea.Model, tea.Cmd) {
+
+	post, ok := m.selectedPost()
+	if !ok || m.focus == focusFeeds {
+		return m, nil
+	}
+
+	return m.setRead(post, !post.ReadAt.Valid)
+}
+
+// setRead updates the post and the unread count right away, and the
+// database in the background.
+func (m model) setRead(post database.GetPostsForFeedForUserRow, read bool) (tea.Model, tea.Cmd) {
+
+	q, userID := m.q, m.user.ID
+
+	m.posts[m.postCursor].ReadAt = sql.NullTime{Time: time.Now(), Valid: read}
+	if read {
+		m.feeds[m.feedCursor].UnreadCount--
+	} else {
+		m.feeds[m.feedCursor].UnreadCount++
+	}
+
+	return m, func() tea.Msg {
+		var err error
+		if read {
+			err = q.MarkPostRead(context.Background(), database.MarkPostReadParams{UserID: userID, PostID: post.ID})
+		} else {
+			err = q.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{UserID: userID, PostID: post.ID})
+		}
+		if err != nil {
+			return errMsg{err}
+		}
+		return nil
+	}
+}
+
+func (m model) toggleSaved() (tea.Model, tea.Cmd) {
+
+	post, ok := m.selectedPost()
+	if !ok || m.focus == focusFeeds {
+		return m, nil
+	}
+
+	q, userID := m.q, m.user.ID
+	save := !post.SavedAt.Valid
+
+	m.posts[m.postCursor].SavedAt = sql.NullTime{Time: time.Now(), Valid: save}
+
+	return m, func() tea.Msg {
+		var err error
+		if save {
+			err = q.SavePost(context.Background(), database.SavePostParams{UserID: userID, PostID: post.ID})
+		} else {
+			err = q.UnsavePost(context.Background(), database.UnsavePostParams{UserID: userID, PostID: post.ID})
+		}
+		if err != nil {
+			return errMsg{err}
+		}
+		if save {
+			return statusMsg("saved")
+		}
+		return statusMsg("removed from saved")
+	}
+}
+
+// nextUnread opens the next unread post of the current feed, or the first
+// one of the next feed that has any.
+func (m model) nextUnread() (tea.Model, tea.Cmd) {
+
+	start := m.postCursor
+	if m.focus == focusPost {
+		start++
+	}
+
+	for i := start; i < len(m.posts); i++ {
+		if !m.posts[i].ReadAt.Valid {
+			m.postCursor = i
+			return m.openPost()
+		}
+	}
+
+	for offset := 1; offset <= len(m.feeds); offset++ {
+		i := (m.feedCursor + offset) % len(m.feeds)
+		if m.feeds[i].UnreadCount > 0 {
+			m.feedCursor = i
+			m.openNextUnread = true
+			return m, m.loadPosts(m.feeds[i].ID)
+		}
+	}
+
+	m.status = "no unread posts"
+
+	return m, nil
+}
+
+// openInBrowser hands postURL to the desktop's opener. Post urls come from
+// the feeds, so only absolute http and https urls are passed on; anything
+// else could start another program or be taken for an option.
+func openInBrowser(postURL string) tea.Cmd {
+
+	return func() tea.Msg {
+
+		link, err := url.Parse(postURL)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return errMsg{fmt.Errorf("not opening %q, only http and https links can be opened", postURL)}
+		}
+
+		target := link.String()
+
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", target)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+		default:
+			cmd = exec.Command("xdg-open", target)
+		}
+
+		if err := cmd.Start(); err != nil {
+			return errMsg{fmt.Errorf("couldn't open browser: %w", err)}
+		}
+
+		return statusMsg("opened " + target)
+	}
+}
+
+func (m model) View() string {
+
+	if m.width == 0 {
+		return "loading..."
+	}
+
+	header := titleStyle.Render(fmt.Sprintf("gator - %v", m.user.Name))
+
+	footer := dimStyle.Render(helpText)
+	if m.status != "" {
+		footer = m.status
+	}
+
+	bodyHeight := max(m.height-2, 1)
+
+	var body string
+	if m.focus == focusPost {
+		body = m.viewPost(bodyHeight)
+	} else {
+		feedWidth := min(40, m.width/3)
+		feeds := paneStyle.Width(feedWidth).Height(bodyHeight).Render(m.viewFeeds(feedWidth, bodyHeight))
+		posts := m.viewPosts(m.width-feedWidth-2, bodyHeight)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, feeds, " ", posts)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, footer)
+}
+
+func (m model) viewFeeds(width int, height int) string {
+
+	if len(m.feeds) == 0 {
+		return dimStyle.Render("no followed feeds")
+	}
+
+	lines := make([]string, 0, len(m.feeds))
+	for i, feed := range m.feeds {
+
+		name := feed.DisplayName
+		if feed.FolderName.Valid {
+			name = feed.FolderName.String + "/" + name
+		}
+
+		line := fmt.Sprintf("%v (%d)", name, feed.UnreadCount)
+		if feed.UnreadCount == 0 {
+			line = dimStyle.Render(line)
+		}
+
+		lines = append(lines, m.cursorLine(line, width, i == m.feedCursor, m.focus == focusFeeds))
+	}
+
+	return window(lines, m.feedCursor, height)
+}
+
+func (m model) viewPosts(width int, height int) string {
+
+	if len(m.posts) == 0 {
+		return dimStyle.Render("no posts yet")
+	}
+
+	lines := make([]string, 0, len(m.posts))
+	for i, post := range m.posts {
+
+		marker := " "
+		if !post.ReadAt.Valid {
+			marker = "●"
+		}
+		if post.SavedAt.Valid {
+			marker += "★"
+		} else {
+			marker += " "
+		}
+
+		line := fmt.Sprintf("%v %v", marker, post.Title)
+		if post.ReadAt.Valid {
+			line = dimStyle.Render(line)
+		}
+
+		lines = append(lines, m.cursorLine(line, width, i == m.postCursor, m.focus == focusPosts))
+	}
+
+	return window(lines, m.postCursor, height)
+}
+
+func (m model) cursorLine(line string, width int, selected bool, focused bool) string {
+
+	style := lipgloss.NewStyle().MaxWidth(width)
+	if selected && focused {
+		style = style.Inherit(selectedStyle)
+	} else if selected {
+		style = style.Bold(true)
+	}
+
+	return style.Render(line)
+}
+
+// postLines is the selected post rendered and wrapped to the screen width.
+func (m model) postLines() []string {
+
+	post, ok := m.selectedPost()
+	if !ok {
+		return nil
+	}
+
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(post.Title) + "\n")
+	if feed, ok := m.selectedFeed(); ok {
+		b.WriteString(feed.DisplayName)
+	}
+	if post.PublishedAt.Valid {
+		b.WriteString(" - " + post.PublishedAt.Time.Local().Format("Mon 2 Jan 2006 15:04"))
+	}
+	if post.SavedAt.Valid {
+		b.WriteString(" ★")
+	}
+	b.WriteString("\n" + dimStyle.Render(post.Url) + "\n\n")
+	b.WriteString(htmlToText(post.Description.String))
+
+	wrapped := lipgloss.NewStyle().Width(max(m.width-2, 10)).Render(b.String())
+
+	return strings.Split(wrapped, "\n")
+}
+
+func (m model) viewPost(height int) string {
+
+	lines := m.postLines()
+
+	start := min(m.postScroll, max(len(lines)-1, 0))
+	end := min(start+height, len(lines))
+
+	return strings.Join(lines[start:end], "\n")
+}
+
+// window returns the lines that fit in height, scrolled to keep cursor
+// visible.
+func window(lines []string, cursor int, height int) string {
+
+	start := 0
+	if cursor >= height {
+		start = cursor - height + 1
+	}
+	end := min(start+height, len(lines))
+
+	return strings.Join(lines[start:end], "\n")
+}
+
+func clamp(value int, low int, high int) int {
+	return max(low, min(value, high))
+}
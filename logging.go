@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// newLogger builds the logger for diagnostics, written to stderr so they
+// never mix with the output of commands on stdout. Timestamps are left out
+// of text logs on a terminal, where the user is watching them happen.
+func newLogger(format string, level slog.Level) (*slog.Logger, error) {
+
+	options := &slog.HandlerOptions{Level: level}
+
+	switch format {
+	case formatJSON:
+		return slog.New(slog.NewJSONHandler(os.Stderr, options)), nil
+
+	case formatText:
+		if term.IsTerminal(int(os.Stderr.Fd())) {
+			options.ReplaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+				if len(groups) == 0 && attr.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return attr
+			}
+		}
+		return slog.New(slog.NewTextHandler(os.Stderr, options)), nil
+	}
+
+	return nil, fmt.Errorf("unknown log format %v, expected text or json", format)
+}
+
+// logLevel turns --verbose and --quiet into the lowest level logged.
+func logLevel(verbose bool, quiet bool) (slog.Level, error) {
+
+	switch {
+	case verbose && quiet:
+		return 0, fmt.Errorf("--verbose and --quiet cannot be combined")
+	case verbose:
+		return slog.LevelDebug, nil
+	case quiet:
+		return slog.LevelWarn, nil
+	}
+
+	return slog.LevelInfo, nil
+}
+
+// exitOnError logs err as the reason command failed and exits.
+func exitOnError(logger *slog.Logger, name string, err error) {
+
+	if err == nil {
+		return
+	}
+
+	logger.LogAttrs(context.Background(), slog.LevelError, err.Error(), slog.String("command", name))
+	os.Exit(1)
+}
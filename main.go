@@ -5,11 +5,10 @@ import (
 	"database/sql"
 	"encoding/xml"
 	"errors"
+	"flag"
 	"fmt"
 	"html"
-	"io"
-	"log"
-	"net/http"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
@@ -17,18 +16,24 @@ import (
 
 	"github.com/Omorfii/aggregator/internal/config"
 	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/Omorfii/aggregator/internal/fetch"
+	"github.com/Omorfii/aggregator/internal/metrics"
+	"github.com/Omorfii/aggregator/internal/storage"
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
 )
 
 type state struct {
-	db  *database.Queries
-	cfg *config.Config
+	db     database.Querier
+	store  *storage.Store
+	cfg    *config.Config
+	logger *slog.Logger
+	format string
 }
 
 type command struct {
 	name      string
 	arguments []string
+	usage     string
 }
 
 type RSSFeed struct {
@@ -47,25 +52,9 @@ type RSSItem struct {
 	PubDate     string `xml:"pubDate"`
 }
 
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+func fetchFeed(ctx context.Context, client *fetch.Client, feedURL string) (*RSSFeed, error) {
 
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("User-Agent", "gator")
-
-	client := &http.Client{}
-
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	defer res.Body.Close()
-
-	byt, err := io.ReadAll(res.Body)
+	byt, err := client.Get(ctx, feedURL)
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +62,8 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	var feed RSSFeed
 
 	if err = xml.Unmarshal(byt, &feed); err != nil {
-		return nil, err
+		metrics.ParseErrors.Inc()
+		return nil, fmt.Errorf("parsing feed: %w", err)
 	}
 
 	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
@@ -89,13 +79,19 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 
 func handlerLogin(s *state, cmd command) error {
 
-	if len(cmd.arguments) <= 0 {
+	flags := newFlagSet(cmd)
+	resetToken := flags.String("reset-token", "", "choose a new password with a token from gator passwd --user")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	if flags.NArg() <= 0 {
 		return fmt.Errorf("no username given")
 	}
 
-	firstArgument := cmd.arguments[0]
+	firstArgument := flags.Arg(0)
 
-	_, err := s.db.GetUser(context.Background(), firstArgument)
+	user, err := s.db.GetUser(context.Background(), firstArgument)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("user does not exist")
@@ -103,7 +99,19 @@ func handlerLogin(s *state, cmd command) error {
 		return err
 	}
 
-	err = s.cfg.SetUser(firstArgument)
+	// Accounts created before passwords existed, and users who forgot
+	// theirs, choose one with a reset token an admin gave them.
+	if *resetToken != "" {
+		if err := resetPassword(s, user, *resetToken); err != nil {
+			return err
+		}
+	} else if user.PasswordHash == "" {
+		return fmt.Errorf("user %v has no password yet, ask an admin for a reset token with gator passwd --user %v", user.Name, user.Name)
+	} else if err := checkPassword(user, "Password: "); err != nil {
+		return err
+	}
+
+	err = startSession(s, user)
 	if err != nil {
 		return err
 	}
@@ -128,83 +136,85 @@ func handlerRegister(s *state, cmd command) error {
 		return err
 	}
 
-	uuid := uuid.New()
-
-	parameters := database.CreateUserParams{
-		ID:        uuid,
-		Name:      firstArgument,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	user, err := s.db.CreateUser(context.Background(), parameters)
+	hash, err := readNewPassword()
 	if err != nil {
 		return err
 	}
 
-	err = s.cfg.SetUser(firstArgument)
+	// The first account administers the install.
+	users, err := s.db.GetUsers(context.Background())
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("User was created: %+v\n", user)
+	role := roleMember
+	if len(users) == 0 {
+		role = roleAdmin
+	}
 
-	return nil
-}
+	uuid := uuid.New()
 
-func handlerReset(s *state, cmd command) error {
+	parameters := database.CreateUserParams{
+		ID:           uuid,
+		Name:         firstArgument,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		PasswordHash: hash,
+		Role:         role,
+	}
 
-	err := s.db.DeleteAllUsers(context.Background())
+	user, err := s.db.CreateUser(context.Background(), parameters)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("All users have been deleted\n")
-	return nil
-}
-
-func handlerUsers(s *state, cmd command) error {
-
-	users, err := s.db.GetUsers(context.Background())
+	err = startSession(s, user)
 	if err != nil {
 		return err
 	}
 
-	for i := 0; i < len(users); i++ {
-		if users[i].Name == s.cfg.CurrentUser {
-			fmt.Printf("%v (current)\n", users[i].Name)
-		} else {
-			fmt.Printf("%v\n", users[i].Name)
-		}
-	}
+	fmt.Printf("User was created: %v\n", user.Name)
 
 	return nil
 }
 
-func handlerAgg(s *state, cmd command) error {
+func handlerUsers(s *state, cmd command) error {
 
-	if len(cmd.arguments) <= 0 {
-		return fmt.Errorf("no time given")
+	users, err := s.db.GetUsers(context.Background())
+	if err != nil {
+		return err
 	}
 
-	firstArgument := cmd.arguments[0]
+	// Listing users does not need a login, there is just no current one.
+	curentUser, _ := currentUser(s)
 
-	timeDuration, err := time.ParseDuration(firstArgument)
-	if err != nil {
-		return nil
+	if s.format == formatJSON {
+		type userOutput struct {
+			Name    string `json:"name"`
+			Role    string `json:"role"`
+			Current bool   `json:"current"`
+		}
+		output := make([]userOutput, 0, len(users))
+		for _, user := range users {
+			output = append(output, userOutput{Name: user.Name, Role: user.Role, Current: user.ID == curentUser.ID})
+		}
+		return printJSON(output)
 	}
 
-	fmt.Printf("Collecting feeds every %v\n", timeDuration)
+	for i := 0; i < len(users); i++ {
+		name := users[i].Name
+		if users[i].Role == roleAdmin {
+			name += " [admin]"
+		}
 
-	ticker := time.NewTicker(timeDuration)
-	for ; ; <-ticker.C {
-		err := scrapeFeeds(s, cmd)
-		if err != nil {
-			return err
+		if users[i].ID == curentUser.ID {
+			fmt.Printf("%v (current)\n", name)
+		} else {
+			fmt.Printf("%v\n", name)
 		}
-		fmt.Printf("scrapeFeeds success\n")
 	}
 
+	return nil
 }
 
 func handlerAddFeed(s *state, cmd command, user database.User) error {
@@ -261,6 +271,13 @@ func handlerFeeds(s *state, _ command) error {
 		return err
 	}
 
+	type feedOutput struct {
+		Name      string `json:"name"`
+		Url       string `json:"url"`
+		CreatedBy string `json:"created_by"`
+	}
+	output := make([]feedOutput, 0, len(feeds))
+
 	for _, feed := range feeds {
 
 		creator, err := s.db.GetUserFromID(context.Background(), feed.UserID)
@@ -268,11 +285,19 @@ func handlerFeeds(s *state, _ command) error {
 			return err
 		}
 
+		if s.format == formatJSON {
+			output = append(output, feedOutput{Name: feed.Name, Url: feed.Url, CreatedBy: creator.Name})
+			continue
+		}
+
 		fmt.Printf("Feed name: %v\n", feed.Name)
 		fmt.Printf("Feed url: %v\n", feed.Url)
-		fmt.Printf("User that created the feed: %v\n", creator)
+		fmt.Printf("User that created the feed: %v\n", creator.Name)
 	}
 
+	if s.format == formatJSON {
+		return printJSON(output)
+	}
 	return nil
 }
 
@@ -309,21 +334,62 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 	return nil
 }
 
-func handlerFollowing(s *state, _ command, user database.User) error {
+func handlerFollowing(s *state, cmd command, user database.User) error {
 
-	feedsFollowed, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	var folderFilter string
+
+	if len(cmd.arguments) > 0 {
+		folder, err := getFolderFromName(s, user, cmd.arguments[0])
+		if err != nil {
+			return err
+		}
+		folderFilter = folder.Name
+	}
+
+	feedsFollowed, err := s.db.GetFollowedFeedsForUser(context.Background(), user.ID)
 	if err != nil {
 		return err
 	}
 
-	for _, feedFollow := range feedsFollowed {
+	if s.format == formatJSON {
+		type followedFeedOutput struct {
+			Name   string `json:"name"`
+			Url    string `json:"url"`
+			Folder string `json:"folder,omitempty"`
+		}
+		output := make([]followedFeedOutput, 0, len(feedsFollowed))
+		for _, feed := range feedsFollowed {
+			if folderFilter != "" && feed.FolderName.String != folderFilter {
+				continue
+			}
+			output = append(output, followedFeedOutput{Name: feed.DisplayName, Url: feed.Url, Folder: feed.FolderName.String})
+		}
+		return printJSON(output)
+	}
+
+	currentFolder := ""
 
-		feed, err := s.db.GetFeedFromID(context.Background(), feedFollow.FeedID)
-		if err != nil {
-			return err
+	for i, feed := range feedsFollowed {
+
+		folder := feed.FolderName.String
+
+		if folderFilter != "" {
+			if folder == folderFilter {
+				fmt.Printf("%v\n", feed.DisplayName)
+			}
+			continue
+		}
+
+		if i == 0 || folder != currentFolder {
+			if folder == "" {
+				fmt.Println("(no folder)")
+			} else {
+				fmt.Printf("%v/\n", folder)
+			}
+			currentFolder = folder
 		}
 
-		fmt.Printf(feed.Name)
+		fmt.Printf("  %v\n", feed.DisplayName)
 	}
 
 	return nil
@@ -350,64 +416,43 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	return s.db.UnfollowFeed(context.Background(), parameter)
 }
 
-func scrapeFeeds(s *state, _ command) error {
+// handlerRename sets the current user's own title for a followed feed, or
+// clears it when no title is given.
+func handlerRename(s *state, cmd command, user database.User) error {
 
-	feedFetched, err := s.db.GetNextFeedToFetch(context.Background())
-	if err != nil {
-		return err
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no feed url given")
 	}
 
-	err = s.db.MarkFeedFetched(context.Background(), feedFetched.ID)
+	feedFromURL, err := s.db.GetFeed(context.Background(), cmd.arguments[0])
 	if err != nil {
 		return err
 	}
 
-	rssFeed, err := fetchFeed(context.Background(), feedFetched.Url)
+	title := strings.Join(cmd.arguments[1:], " ")
+
+	parameter := database.SetFeedFollowTitleParams{
+		UserID: user.ID,
+		FeedID: feedFromURL.ID,
+		Title: sql.NullString{
+			String: title,
+			Valid:  title != "",
+		},
+	}
+
+	updated, err := s.db.SetFeedFollowTitle(context.Background(), parameter)
 	if err != nil {
 		return err
 	}
 
-	var pubdate sql.NullTime
-
-	for _, item := range rssFeed.Channel.Item {
-
-		description := sql.NullString{
-			String: item.Description,
-			Valid:  item.Description != "",
-		}
-
-		pubtime, err := time.Parse(time.RFC1123Z, item.PubDate)
-		if err != nil {
-			pubdate = sql.NullTime{
-				Time:  time.Time{},
-				Valid: false,
-			}
-		} else {
-			pubdate = sql.NullTime{
-				Time:  pubtime,
-				Valid: true,
-			}
-		}
-
-		parameter := database.CreatePostParams{
-			ID:          uuid.New(),
-			Title:       item.Title,
-			Url:         item.Link,
-			Description: description,
-			PublishedAt: pubdate,
-			FeedID:      feedFetched.ID,
-		}
-
-		post, err := s.db.CreatePost(context.Background(), parameter)
-		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint") {
-				continue
-			}
-			return err
-		}
-
-		fmt.Printf("post successfully created: %v", post)
+	if updated == 0 {
+		return fmt.Errorf("you do not follow this feed")
+	}
 
+	if title == "" {
+		fmt.Printf("Feed %v uses its original title\n", feedFromURL.Name)
+	} else {
+		fmt.Printf("Feed %v renamed to %v\n", feedFromURL.Name, title)
 	}
 
 	return nil
@@ -415,12 +460,19 @@ func scrapeFeeds(s *state, _ command) error {
 
 func handlerBrowse(s *state, cmd command, user database.User) error {
 
+	flags := newFlagSet(cmd)
+	folderName := flags.String("folder", "", "only show posts from feeds in this folder")
+	tagName := flags.String("tag", "", "only show posts with this tag")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
 	var arg int32
 
-	if len(cmd.arguments) <= 0 {
+	if flags.NArg() <= 0 {
 		arg = 2
 	} else {
-		val, err := strconv.Atoi(cmd.arguments[0])
+		val, err := strconv.Atoi(flags.Arg(0))
 		if err != nil {
 			return err
 		}
@@ -432,13 +484,45 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 		Limit:  arg,
 	}
 
+	if *folderName != "" {
+		folder, err := getFolderFromName(s, user, *folderName)
+		if err != nil {
+			return err
+		}
+		parameter.FolderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	if *tagName != "" {
+		parameter.Tag = sql.NullString{String: normalizeTag(*tagName), Valid: true}
+	}
+
 	posts, err := s.db.GetPostsForUser(context.Background(), parameter)
 	if err != nil {
-		return nil
+		return err
+	}
+
+	if s.format == formatJSON {
+		type postOutput struct {
+			ID          uuid.UUID  `json:"id"`
+			Feed        string     `json:"feed"`
+			Title       string     `json:"title"`
+			Url         string     `json:"url"`
+			PublishedAt *time.Time `json:"published_at,omitempty"`
+		}
+		output := make([]postOutput, 0, len(posts))
+		for _, post := range posts {
+			postJSON := postOutput{ID: post.ID, Feed: post.FeedName, Title: post.Title, Url: post.Url}
+			if post.PublishedAt.Valid {
+				postJSON.PublishedAt = &post.PublishedAt.Time
+			}
+			output = append(output, postJSON)
+		}
+		return printJSON(output)
 	}
 
 	for _, post := range posts {
-		fmt.Printf("%v\n", post)
+		fmt.Printf("%v | %v\n", post.FeedName, post.Title)
+		fmt.Printf("  %v\n", post.Url)
 	}
 
 	return nil
@@ -448,9 +532,7 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 
 	return func(s *state, cmd command) error {
 
-		curentUserName := s.cfg.CurrentUser
-
-		curentUser, err := s.db.GetUser(context.Background(), curentUserName)
+		curentUser, err := currentUser(s)
 		if err != nil {
 			return err
 		}
@@ -459,81 +541,171 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 	}
 }
 
-type commands struct {
-	handlers map[string]func(*state, command) error
-}
+func main() {
 
-func (c *commands) run(s *state, cmd command) error {
+	globalFlags := flag.NewFlagSet("gator", flag.ContinueOnError)
+	profile := globalFlags.String("profile", "", "config profile to use instead of the current one")
+	configPath := globalFlags.String("config", "", "config file to use instead of the default one")
+	dbURL := globalFlags.String("db-url", "", "database url, overriding db_url from the config")
+	verbose := globalFlags.Bool("verbose", false, "log debugging details too")
+	quiet := globalFlags.Bool("quiet", false, "only log warnings and errors")
+	format := globalFlags.String("format", formatText, "output of listing commands, text or json")
+	logFormat := globalFlags.String("log-format", formatText, "format of the logs written to stderr, text or json")
 
-	function, exists := c.handlers[cmd.name]
-	if exists {
-		return function(s, cmd)
-	} else {
-		return fmt.Errorf("command not found")
+	currentCommands := commands{
+		handlers:    make(map[string]commandInfo),
+		globalFlags: globalFlags,
 	}
 
-}
+	globalFlags.Usage = currentCommands.printHelp
 
-func (c *commands) register(name string, f func(*state, command) error) error {
+	var arguments []string
 
-	if _, exists := c.handlers[name]; exists {
-		return fmt.Errorf("handler for command %s already exists", name)
+	// The words being completed are not arguments for gator itself.
+	if len(os.Args) > 1 && os.Args[1] == completeCommand {
+		arguments = os.Args[1:]
+	} else {
+		var err error
+		arguments, err = parseGlobalFlags(globalFlags, os.Args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			os.Exit(1)
+		}
 	}
-	c.handlers[name] = f
-	return nil
-}
 
-func main() {
+	if *format != formatText && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "unknown format %v, expected text or json\n", *format)
+		os.Exit(1)
+	}
 
-	cfg, err := config.Read()
+	level, err := logLevel(*verbose, *quiet)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	db, err := sql.Open("postgres", cfg.Url)
+	logger, err := newLogger(*logFormat, level)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	config.SetPath(*configPath)
+
+	overrides := make(map[string]string)
+	if *dbURL != "" {
+		overrides["db_url"] = *dbURL
+	}
+
+	currentCommands.register("help", "help [command]", "list commands, or show how to use one", currentCommands.handlerHelp)
+	currentCommands.register("completion", "completion bash|zsh|fish", "print a shell completion script", currentCommands.handlerCompletion)
+	currentCommands.register(completeCommand, completeCommand+" <words...> <current word>", "complete a command line, used by the completion scripts", currentCommands.handlerComplete)
+	currentCommands.register("login", "login [--reset-token <token>] <name>", "log in, asking for the password", handlerLogin)
+	currentCommands.register("register", "register <name>", "create a user and log in as them", handlerRegister)
+	currentCommands.register("logout", "logout", "end the current session", handlerLogout)
+	currentCommands.register("passwd", "passwd [--user <name>]", "change your password, or give a user a reset token (admin only)", middlewareLoggedIn(handlerPasswd))
+	currentCommands.register("promote", "promote <name>", "make a user an admin (admin only)", middlewareAdmin(handlerPromote))
+	currentCommands.register("demote", "demote <name>", "make an admin a regular member (admin only)", middlewareAdmin(handlerDemote))
+	currentCommands.register("deleteuser", "deleteuser [--yes] <name>", "delete a user and everything they own (admin only)", middlewareAdmin(handlerDeleteUser))
+	currentCommands.register("reset", "reset [--user <name>] [--posts-only] [--feeds-only] [--yes]", "delete data after confirmation and a backup (admin only)", middlewareAdmin(handlerReset))
+	currentCommands.register("users", "users", "list users", handlerUsers)
+	currentCommands.register("agg", "agg [--daemon] [--feeds <count>] [--listen <address>] [--pid-file <path>] [--shutdown-timeout <duration>] <time between requests>", "fetch feeds until stopped, e.g. agg 1m", handlerAgg)
+	currentCommands.register("addfeed", "addfeed <name> <url>", "add a feed and follow it", middlewareLoggedIn(handlerAddFeed))
+	currentCommands.register("feeds", "feeds", "list every feed", handlerFeeds)
+	currentCommands.register("editfeed", "editfeed [--name <name>] [--url <url>] <feed url>", "change the name or url of a feed you added", middlewareLoggedIn(handlerEditFeed))
+	currentCommands.register("deletefeed", "deletefeed <feed url>", "delete a feed you added, or hand it to its other followers; admins can delete any feed", middlewareLoggedIn(handlerDeleteFeed))
+	currentCommands.register("follow", "follow <url>", "follow a feed", middlewareLoggedIn(handlerFollow))
+	currentCommands.register("following", "following [folder]", "list followed feeds by folder", middlewareLoggedIn(handlerFollowing))
+	currentCommands.register("unfollow", "unfollow <url>", "stop following a feed", middlewareLoggedIn(handlerUnfollow))
+	currentCommands.register("browse", "browse [--folder <name>] [--tag <name>] [limit]", "show the latest posts of followed feeds", middlewareLoggedIn(handlerBrowse))
+	currentCommands.register("read", "read <post id or url>", "mark a post as read", middlewareLoggedIn(handlerRead))
+	currentCommands.register("tui", "tui [--refresh <interval>]", "read feeds in a full-screen terminal interface", middlewareLoggedIn(handlerTUI))
+	currentCommands.register("unread", "unread <post id or url>", "mark a post as unread", middlewareLoggedIn(handlerUnread))
+	currentCommands.register("save", "save <post id or url>", "save a post for later", middlewareLoggedIn(handlerSave))
+	currentCommands.register("unsave", "unsave <post id or url>", "remove a post from the saved ones", middlewareLoggedIn(handlerUnsave))
+	currentCommands.register("prune", "prune [--dry-run]", "delete posts beyond the retention policy (admin only)", middlewareAdmin(handlerPrune))
+	currentCommands.register("addfolder", "addfolder <name>", "create a folder", middlewareLoggedIn(handlerAddFolder))
+	currentCommands.register("folders", "folders", "list your folders", middlewareLoggedIn(handlerFolders))
+	currentCommands.register("renamefolder", "renamefolder <name> <new name>", "rename a folder", middlewareLoggedIn(handlerRenameFolder))
+	currentCommands.register("deletefolder", "deletefolder <name>", "delete a folder, keeping its feeds", middlewareLoggedIn(handlerDeleteFolder))
+	currentCommands.register("movefeed", "movefeed <url> [folder]", "move a followed feed into a folder, or out of it", middlewareLoggedIn(handlerMoveFeed))
+	currentCommands.register("rename", "rename <url> [title]", "give a followed feed your own title, or reset it", middlewareLoggedIn(handlerRename))
+	currentCommands.register("tag", "tag <post id or url> <tag...>", "tag a post with one or more tags", middlewareLoggedIn(handlerTag))
+	currentCommands.register("untag", "untag <post id or url> <tag...>", "remove tags from a post", middlewareLoggedIn(handlerUntag))
+	currentCommands.register("tags", "tags", "list your tags with their post counts", middlewareLoggedIn(handlerTags))
+	currentCommands.register("addrule", "addrule [--regex] <field> <pattern> <action> [tag]", "add a filter rule applied to new posts", middlewareLoggedIn(handlerAddRule))
+	currentCommands.register("rules", "rules", "list your filter rules", middlewareLoggedIn(handlerRules))
+	currentCommands.register("deleterule", "deleterule <id>", "delete a filter rule", middlewareLoggedIn(handlerDeleteRule))
+	currentCommands.register("applyrules", "applyrules", "apply your filter rules to existing posts", middlewareLoggedIn(handlerApplyRules))
+	currentCommands.register("migrate", "migrate up|down|status", "apply, roll back (admin only) or list schema migrations", handlerMigrate)
+	currentCommands.register("backup", "backup <file>", "write a backup of the whole database (admin only)", middlewareAdmin(handlerBackup))
+	currentCommands.register("restore", "restore [--on-conflict skip|fail] <file>", "restore a backup (admin only)", middlewareAdmin(handlerRestore))
+	currentCommands.register("profile", "profile list|use|add|remove", "manage config profiles", handlerProfile)
+	currentCommands.register("config", "config get <key> | set <key> <value> | show", "read or change settings", handlerConfig)
+
+	if len(arguments) < 1 {
+		fmt.Fprintln(os.Stderr, "not enough argument")
+		currentCommands.printHelp()
+		os.Exit(1)
 	}
-	defer db.Close()
 
-	dbQueries := database.New(db)
+	userCommand := command{
+		name:      arguments[0],
+		arguments: arguments[1:],
+	}
 
-	currentConfig := state{
-		db:  dbQueries,
-		cfg: &cfg,
+	if wantsHelp(arguments[:1]) {
+		userCommand = command{name: "help"}
 	}
 
-	currentCommands := commands{
-		handlers: make(map[string]func(*state, command) error),
+	if !currentCommands.exists(userCommand.name) {
+		fmt.Fprintln(os.Stderr, currentCommands.unknownCommand(userCommand.name))
+		os.Exit(1)
 	}
 
-	currentCommands.register("login", handlerLogin)
-	currentCommands.register("register", handlerRegister)
-	currentCommands.register("reset", handlerReset)
-	currentCommands.register("users", handlerUsers)
-	currentCommands.register("agg", handlerAgg)
-	currentCommands.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	currentCommands.register("feeds", handlerFeeds)
-	currentCommands.register("follow", middlewareLoggedIn(handlerFollow))
-	currentCommands.register("following", middlewareLoggedIn(handlerFollowing))
-	currentCommands.register("unfollow", middlewareLoggedIn(handlerUnfollow))
-	currentCommands.register("browse", middlewareLoggedIn(handlerBrowse))
+	if userCommand.name == completeCommand {
+		completionState := newCompletionState(*profile, overrides, logger)
+		if completionState.store != nil {
+			defer completionState.store.Close()
+		}
+		currentCommands.run(completionState, userCommand)
+		return
+	}
 
-	arguments := os.Args
+	// Help and profiles work without touching any database, so that a
+	// profile with a broken db_url can still be fixed or removed.
+	if userCommand.name == "help" || userCommand.name == "completion" || userCommand.name == "profile" || wantsHelp(userCommand.arguments) {
+		exitOnError(logger, userCommand.name, currentCommands.run(&state{logger: logger}, userCommand))
+		return
+	}
 
-	if len(arguments) < 2 {
-		fmt.Println("not enough argument")
-		os.Exit(1)
+	cfg, err := config.Read(*profile, overrides)
+	exitOnError(logger, userCommand.name, err)
+
+	if userCommand.name == "config" {
+		exitOnError(logger, userCommand.name, currentCommands.run(&state{cfg: &cfg, logger: logger, format: *format}, userCommand))
+		return
 	}
 
-	userCommand := command{
-		name:      arguments[1],
-		arguments: arguments[2:],
+	store, err := storage.Open(cfg.Url)
+	exitOnError(logger, userCommand.name, err)
+	defer store.Close()
+
+	currentConfig := state{
+		db:     store,
+		store:  store,
+		cfg:    &cfg,
+		logger: logger,
+		format: *format,
 	}
 
-	if err := currentCommands.run(&currentConfig, userCommand); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if userCommand.name != "migrate" {
+		exitOnError(logger, userCommand.name, checkSchema(store))
 	}
 
+	exitOnError(logger, userCommand.name, currentCommands.run(&currentConfig, userCommand))
+
 }
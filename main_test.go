@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/config"
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/Omorfii/aggregator/internal/storage/storagetest"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testPassword is the password of the users made by addUser.
+const testPassword = "password1"
+
+// newTestState returns a state on a fresh SQLite database, with its config
+// file in a temporary directory and nobody logged in.
+func newTestState(t *testing.T) *state {
+
+	t.Helper()
+
+	config.SetPath(filepath.Join(t.TempDir(), "config.json"))
+	t.Cleanup(func() { config.SetPath("") })
+
+	cfg, err := config.Read("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := storagetest.Open(t)
+
+	return &state{
+		db:     store,
+		store:  store,
+		cfg:    &cfg,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		format: formatText,
+	}
+}
+
+// addUser creates a user with role, with testPassword as their password or
+// none, like the accounts made before passwords existed.
+func addUser(t *testing.T, s *state, name string, role string, withPassword bool) database.User {
+
+	t.Helper()
+
+	parameters := database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      name,
+		Role:      role,
+	}
+
+	if withPassword {
+		hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parameters.PasswordHash = string(hash)
+	}
+
+	user, err := s.db.CreateUser(context.Background(), parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// addFeed creates a feed added by user.
+func addFeed(t *testing.T, s *state, user database.User, name string, url string) database.Feed {
+
+	t.Helper()
+
+	feed, err := s.db.CreateFeed(context.Background(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      name,
+		Url:       url,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return feed
+}
+
+// follow makes user follow feed.
+func follow(t *testing.T, s *state, user database.User, feed database.Feed) {
+
+	t.Helper()
+
+	_, err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// logIn starts a session for user, as gator login does.
+func logIn(t *testing.T, s *state, user database.User) {
+
+	t.Helper()
+
+	if err := startSession(s, user); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// withInput makes prompts read lines for the rest of the test.
+func withInput(t *testing.T, lines ...string) {
+
+	previous := stdin
+	stdin = bufio.NewReader(strings.NewReader(strings.Join(lines, "\n") + "\n"))
+	t.Cleanup(func() { stdin = previous })
+}
+
+// captureOutput returns what run prints to stdout.
+func captureOutput(t *testing.T, run func()) string {
+
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = previous }()
+
+	output := make(chan string)
+	go func() {
+		content, _ := io.ReadAll(reader)
+		output <- string(content)
+	}()
+
+	run()
+
+	writer.Close()
+
+	return <-output
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Omorfii/aggregator/internal/storage"
+	"github.com/pressly/goose/v3"
+)
+
+// checkSchema refuses to go on when the database is not at the schema
+// version this binary was built with.
+func checkSchema(store *storage.Store) error {
+
+	provider, err := store.MigrationProvider()
+	if err != nil {
+		return err
+	}
+
+	current, target, err := provider.GetVersions(context.Background())
+	if err != nil {
+		return fmt.Errorf("could not read database schema version: %w", err)
+	}
+
+	if current > target {
+		return fmt.Errorf("database schema is at version %d but this gator only knows version %d, upgrade gator", current, target)
+	}
+
+	pending, err := provider.HasPending(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if pending {
+		return fmt.Errorf("database schema is out of date (version %d, need %d), run \"gator migrate up\"", current, target)
+	}
+
+	return nil
+}
+
+func handlerMigrate(s *state, cmd command) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("usage: migrate up|down|status")
+	}
+
+	provider, err := s.store.MigrationProvider()
+	if err != nil {
+		return err
+	}
+
+	switch cmd.arguments[0] {
+	case "up":
+		results, err := provider.Up(context.Background())
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Println("Database schema is up to date")
+		}
+		for _, result := range results {
+			fmt.Printf("Applied %v (%v)\n", result.Source.Path, result.Duration)
+		}
+		if err := listUsersWithoutPassword(s); err != nil {
+			return err
+		}
+
+	case "down":
+		// Up and status have to work before any user exists, but rolling
+		// back drops tables and columns, so it is for admins only.
+		user, err := currentUser(s)
+		if err != nil {
+			return err
+		}
+		if user.Role != roleAdmin {
+			return fmt.Errorf("migrate down can only be run by an admin")
+		}
+
+		result, err := provider.Down(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %v (%v)\n", result.Source.Path, result.Duration)
+
+	case "status":
+		statuses, err := provider.Status(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.State == goose.StateApplied {
+				fmt.Printf("%v applied at %v\n", status.Source.Path, status.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("%v pending\n", status.Source.Path)
+			}
+		}
+
+	default:
+		return fmt.Errorf("unknown migrate action %v, expected up, down or status", cmd.arguments[0])
+	}
+
+	return nil
+}
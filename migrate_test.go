@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMigrateDownNeedsAdmin(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		role    string
+		wantErr bool
+	}{
+		{name: "logged out", wantErr: true},
+		{name: "member", role: roleMember, wantErr: true},
+		{name: "admin", role: roleAdmin},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			if test.role != "" {
+				logIn(t, s, addUser(t, s, "alice", test.role, true))
+			}
+
+			provider, err := s.store.MigrationProvider()
+			if err != nil {
+				t.Fatal(err)
+			}
+			before, err := provider.GetDBVersion(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = handlerMigrate(s, command{name: "migrate", arguments: []string{"down"}})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("migrate down = %v, want error %v", err, test.wantErr)
+			}
+
+			after, err := provider.GetDBVersion(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rolledBack := after < before; rolledBack == test.wantErr {
+				t.Errorf("schema went from version %d to %d", before, after)
+			}
+		})
+	}
+}
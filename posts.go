@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// getPostFromArgument looks a post up by id, falling back to its url.
+func getPostFromArgument(s *state, argument string) (database.Post, error) {
+
+	var post database.Post
+	var err error
+
+	if id, parseErr := uuid.Parse(argument); parseErr == nil {
+		post, err = s.db.GetPostFromID(context.Background(), id)
+	} else {
+		post, err = s.db.GetPostByURL(context.Background(), argument)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Post{}, fmt.Errorf("post does not exist")
+	}
+
+	return post, err
+}
+
+func handlerRead(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no post given")
+	}
+
+	post, err := getPostFromArgument(s, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	parameter := database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+
+	return s.db.MarkPostRead(context.Background(), parameter)
+}
+
+func handlerUnread(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no post given")
+	}
+
+	post, err := getPostFromArgument(s, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	parameter := database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+
+	return s.db.MarkPostUnread(context.Background(), parameter)
+}
+
+func handlerSave(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no post given")
+	}
+
+	post, err := getPostFromArgument(s, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	parameter := database.SavePostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+
+	if err := s.db.SavePost(context.Background(), parameter); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved post: %v\n", post.Title)
+
+	return nil
+}
+
+func handlerUnsave(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no post given")
+	}
+
+	post, err := getPostFromArgument(s, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	parameter := database.UnsavePostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+
+	return s.db.UnsavePost(context.Background(), parameter)
+}
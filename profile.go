@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Omorfii/aggregator/internal/config"
+)
+
+func handlerProfile(s *state, cmd command) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("usage: profile list|use|add|remove")
+	}
+
+	action := cmd.arguments[0]
+	arguments := cmd.arguments[1:]
+
+	switch action {
+	case "list":
+		names, current, err := config.Profiles()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if name == current {
+				fmt.Printf("%v (current)\n", name)
+			} else {
+				fmt.Printf("%v\n", name)
+			}
+		}
+
+	case "use":
+		if len(arguments) <= 0 {
+			return fmt.Errorf("no profile name given")
+		}
+		if err := config.UseProfile(arguments[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Now using profile %v\n", arguments[0])
+
+	case "add":
+		if len(arguments) < 2 {
+			return fmt.Errorf("usage: profile add <name> <db_url>")
+		}
+		if err := config.AddProfile(arguments[0], arguments[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Profile %v was created\n", arguments[0])
+
+	case "remove":
+		if len(arguments) <= 0 {
+			return fmt.Errorf("no profile name given")
+		}
+		if err := config.RemoveProfile(arguments[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Profile %v was removed\n", arguments[0])
+
+	default:
+		return fmt.Errorf("unknown profile action %v, expected list, use, add or remove", action)
+	}
+
+	return nil
+}
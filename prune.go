@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const defaultUnreadWindow = 7 * 24 * time.Hour
+
+func handlerPrune(s *state, cmd command, admin database.User) error {
+
+	flags := newFlagSet(cmd)
+	dryRun := flags.Bool("dry-run", false, "report posts that would be removed without deleting them")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	removed, err := prunePosts(s, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Printf("%d posts would be removed\n", removed)
+	} else {
+		fmt.Printf("%d posts removed\n", removed)
+	}
+
+	return nil
+}
+
+// prunePosts applies the configured retention policies to every feed and
+// returns the number of posts removed, or that would be removed if dryRun
+// is set. Saved posts and posts still unread by a follower within the
+// unread window are always kept.
+func prunePosts(s *state, dryRun bool) (int, error) {
+
+	unreadWindow := defaultUnreadWindow
+	if s.cfg.Retention.UnreadWindow != "" {
+		window, err := time.ParseDuration(s.cfg.Retention.UnreadWindow)
+		if err != nil {
+			return 0, fmt.Errorf("invalid retention unread_window: %w", err)
+		}
+		unreadWindow = window
+	}
+
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	// Post times have no time zone and are written in UTC, so the cutoffs
+	// are computed in UTC too rather than in local time.
+	now := time.Now().UTC()
+
+	removed := 0
+
+	for _, feed := range feeds {
+
+		policy := s.cfg.Retention.PolicyFor(feed.Url)
+		if policy.MaxAge == "" && policy.MaxPosts <= 0 {
+			continue
+		}
+
+		parameter := database.GetPrunablePostsForFeedParams{
+			FeedID:      feed.ID,
+			MaxPosts:    sql.NullInt32{Int32: policy.MaxPosts, Valid: policy.MaxPosts > 0},
+			UnreadSince: now.Add(-unreadWindow),
+		}
+
+		if policy.MaxAge != "" {
+			maxAge, err := time.ParseDuration(policy.MaxAge)
+			if err != nil {
+				return removed, fmt.Errorf("invalid retention max_age for %v: %w", feed.Url, err)
+			}
+			parameter.OlderThan = sql.NullTime{Time: now.Add(-maxAge), Valid: true}
+		}
+
+		posts, err := s.db.GetPrunablePostsForFeed(context.Background(), parameter)
+		if err != nil {
+			return removed, err
+		}
+
+		if len(posts) == 0 {
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("%v: %d posts would be removed\n", feed.Name, len(posts))
+			for _, post := range posts {
+				fmt.Printf("  %v (%v)\n", post.Title, post.Url)
+			}
+			removed += len(posts)
+			continue
+		}
+
+		ids := make([]uuid.UUID, 0, len(posts))
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+
+		if err := s.db.DeletePosts(context.Background(), ids); err != nil {
+			return removed, err
+		}
+
+		removed += len(posts)
+	}
+
+	return removed, nil
+}
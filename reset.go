@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Omorfii/aggregator/internal/backup"
+	"github.com/Omorfii/aggregator/internal/database"
+)
+
+// handlerReset deletes data in the requested scope after asking for
+// confirmation and writing a backup of the whole database.
+func handlerReset(s *state, cmd command, admin database.User) error {
+
+	flags := newFlagSet(cmd)
+	userName := flags.String("user", "", "only delete this user, or with --feeds-only the feeds they added")
+	postsOnly := flags.Bool("posts-only", false, "only delete posts")
+	feedsOnly := flags.Bool("feeds-only", false, "only delete feeds, with their follows and posts")
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	if *postsOnly && *feedsOnly {
+		return fmt.Errorf("--posts-only and --feeds-only cannot be combined")
+	}
+
+	if *postsOnly && *userName != "" {
+		return fmt.Errorf("--posts-only cannot be combined with --user")
+	}
+
+	var user database.User
+
+	if *userName != "" {
+		var err error
+		user, err = s.db.GetUser(context.Background(), *userName)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user does not exist")
+		} else if err != nil {
+			return err
+		}
+
+		if !*feedsOnly {
+			if err := checkNotLastAdmin(s, user); err != nil {
+				return err
+			}
+		}
+	}
+
+	var scope string
+
+	switch {
+	case *postsOnly:
+		scope = "all posts"
+	case *feedsOnly && *userName != "":
+		scope = fmt.Sprintf("every feed added by %v, with their follows and posts", user.Name)
+	case *feedsOnly:
+		scope = "all feeds, follows and posts"
+	case *userName != "":
+		scope = fmt.Sprintf("user %v and everything they own, handing feeds others follow over to them", user.Name)
+	default:
+		scope = "all users, feeds, follows and posts"
+	}
+
+	if !*yes {
+		confirmed, err := confirm(fmt.Sprintf("This will delete %v.", scope))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Reset cancelled")
+			return nil
+		}
+	}
+
+	if err := backupBeforeDelete(s, "reset"); err != nil {
+		return err
+	}
+
+	var err error
+
+	switch {
+	case *postsOnly:
+		err = s.db.DeleteAllPosts(context.Background())
+	case *feedsOnly && *userName != "":
+		err = s.db.DeleteFeedsForUser(context.Background(), user.ID)
+	case *feedsOnly:
+		err = s.db.DeleteAllFeeds(context.Background())
+	case *userName != "":
+		err = deleteUser(s, user)
+	default:
+		err = s.db.DeleteAllUsers(context.Background())
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted %v\n", scope)
+
+	return nil
+}
+
+// backupBeforeDelete writes a backup of the whole database, labelled with
+// the command about to delete data.
+func backupBeforeDelete(s *state, label string) error {
+
+	archive, err := backup.Dump(context.Background(), s.db)
+	if err != nil {
+		return fmt.Errorf("backup failed, nothing was deleted: %w", err)
+	}
+
+	backupPath, err := backup.DefaultPath(label)
+	if err != nil {
+		return err
+	}
+
+	if err := backup.WriteFile(backupPath, archive); err != nil {
+		return fmt.Errorf("backup failed, nothing was deleted: %w", err)
+	}
+
+	fmt.Printf("Backup written to %v\n", backupPath)
+
+	return nil
+}
+
+// confirm prints prompt and reports whether the user typed "yes".
+func confirm(prompt string) (bool, error) {
+
+	fmt.Printf("%v Type \"yes\" to continue: ", prompt)
+
+	answer, err := stdin.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	return strings.TrimSpace(answer) == "yes", nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Omorfii/aggregator/internal/config"
+)
+
+func TestReset(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		arguments string
+		input     string
+		wantErr   bool
+		wantUsers int
+		wantFeeds int
+		wantPosts int
+	}{
+		{name: "everything", arguments: "--yes", wantUsers: 0, wantFeeds: 0, wantPosts: 0},
+		{name: "not confirmed", arguments: "", input: "no", wantUsers: 3, wantFeeds: 2, wantPosts: 2},
+		{name: "confirmed", arguments: "", input: "yes", wantUsers: 0, wantFeeds: 0, wantPosts: 0},
+		{name: "posts only", arguments: "--yes --posts-only", wantUsers: 3, wantFeeds: 2, wantPosts: 0},
+		{name: "feeds only", arguments: "--yes --feeds-only", wantUsers: 3, wantFeeds: 0, wantPosts: 0},
+		{name: "feeds of a user", arguments: "--yes --feeds-only --user bob", wantUsers: 3, wantFeeds: 1, wantPosts: 1},
+		{name: "one user", arguments: "--yes --user bob", wantUsers: 2, wantFeeds: 1, wantPosts: 1},
+		{name: "the last admin", arguments: "--yes --user alice", wantErr: true, wantUsers: 3, wantFeeds: 2, wantPosts: 2},
+		{name: "unknown user", arguments: "--yes --user dave", wantErr: true, wantUsers: 3, wantFeeds: 2, wantPosts: 2},
+		{name: "posts of a user", arguments: "--yes --posts-only --user bob", wantErr: true, wantUsers: 3, wantFeeds: 2, wantPosts: 2},
+		{name: "posts and feeds only", arguments: "--yes --posts-only --feeds-only", wantErr: true, wantUsers: 3, wantFeeds: 2, wantPosts: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			t.Setenv("HOME", t.TempDir())
+
+			s := newTestState(t)
+			alice := addUser(t, s, "alice", roleAdmin, true)
+			bob := addUser(t, s, "bob", roleMember, true)
+			addUser(t, s, "carol", roleMember, true)
+
+			server := newFeedServer(t, 0)
+			follow(t, s, bob, addFeed(t, s, bob, "bob's", server.URL+"/bob"))
+			follow(t, s, alice, addFeed(t, s, alice, "alice's", server.URL+"/alice"))
+
+			a := newTestAggregator(t, s, 2, config.FetchConfig{HostInterval: "0s"})
+			if err := a.aggregate(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			withInput(t, test.input)
+
+			var err error
+			captureOutput(t, func() {
+				err = handlerReset(s, command{name: "reset", arguments: strings.Fields(test.arguments)}, alice)
+			})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("reset %v = %v, want error %v", test.arguments, err, test.wantErr)
+			}
+
+			users, err := s.db.GetUsers(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			feeds, err := s.db.GetFeeds(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			posts, err := s.db.GetPosts(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(users) != test.wantUsers || len(feeds) != test.wantFeeds || len(posts) != test.wantPosts {
+				t.Errorf("reset %v left %d users, %d feeds and %d posts, want %d, %d and %d",
+					test.arguments, len(users), len(feeds), len(posts), test.wantUsers, test.wantFeeds, test.wantPosts)
+			}
+		})
+	}
+}
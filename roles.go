@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+)
+
+const (
+	roleAdmin  = "admin"
+	roleMember = "member"
+)
+
+// middlewareAdmin is middlewareLoggedIn for commands only admins may run.
+func middlewareAdmin(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
+
+	return middlewareLoggedIn(func(s *state, cmd command, user database.User) error {
+
+		if user.Role != roleAdmin {
+			return fmt.Errorf("%v can only be run by an admin", cmd.name)
+		}
+
+		return handler(s, cmd, user)
+	})
+}
+
+// checkNotLastAdmin refuses to demote or delete the only admin left, which
+// would leave nobody able to run admin commands.
+func checkNotLastAdmin(s *state, user database.User) error {
+
+	if user.Role != roleAdmin {
+		return nil
+	}
+
+	admins, err := s.db.CountUsersWithRole(context.Background(), roleAdmin)
+	if err != nil {
+		return err
+	}
+
+	if admins <= 1 {
+		return fmt.Errorf("%v is the last admin, promote another user first", user.Name)
+	}
+
+	return nil
+}
+
+func getUserFromArgument(s *state, cmd command) (database.User, error) {
+
+	if len(cmd.arguments) <= 0 {
+		return database.User{}, fmt.Errorf("no username given")
+	}
+
+	user, err := s.db.GetUser(context.Background(), cmd.arguments[0])
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, fmt.Errorf("user does not exist")
+		}
+		return database.User{}, err
+	}
+
+	return user, nil
+}
+
+func setRole(s *state, user database.User, role string) error {
+
+	parameters := database.SetUserRoleParams{
+		ID:        user.ID,
+		Role:      role,
+		UpdatedAt: time.Now(),
+	}
+
+	return s.db.SetUserRole(context.Background(), parameters)
+}
+
+func handlerPromote(s *state, cmd command, admin database.User) error {
+
+	user, err := getUserFromArgument(s, cmd)
+	if err != nil {
+		return err
+	}
+
+	if user.Role == roleAdmin {
+		return fmt.Errorf("%v is already an admin", user.Name)
+	}
+
+	if err := setRole(s, user, roleAdmin); err != nil {
+		return err
+	}
+
+	fmt.Printf("%v is now an admin\n", user.Name)
+
+	return nil
+}
+
+func handlerDemote(s *state, cmd command, admin database.User) error {
+
+	user, err := getUserFromArgument(s, cmd)
+	if err != nil {
+		return err
+	}
+
+	if user.Role != roleAdmin {
+		return fmt.Errorf("%v is not an admin", user.Name)
+	}
+
+	if err := checkNotLastAdmin(s, user); err != nil {
+		return err
+	}
+
+	if err := setRole(s, user, roleMember); err != nil {
+		return err
+	}
+
+	fmt.Printf("%v is now a member\n", user.Name)
+
+	return nil
+}
+
+// handlerDeleteUser deletes one user with everything they own, after a
+// confirmation and a backup like reset.
+func handlerDeleteUser(s *state, cmd command, admin database.User) error {
+
+	flags := newFlagSet(cmd)
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	user, err := getUserFromArgument(s, command{name: cmd.name, arguments: flags.Args()})
+	if err != nil {
+		return err
+	}
+
+	if err := checkNotLastAdmin(s, user); err != nil {
+		return err
+	}
+
+	if !*yes {
+		confirmed, err := confirm(fmt.Sprintf("This will delete user %v and everything they own, handing feeds others follow over to them.", user.Name))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Deletion cancelled")
+			return nil
+		}
+	}
+
+	if err := backupBeforeDelete(s, "deleteuser"); err != nil {
+		return err
+	}
+
+	if err := deleteUser(s, user); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted user %v\n", user.Name)
+
+	return nil
+}
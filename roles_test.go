@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Omorfii/aggregator/internal/database"
+)
+
+func TestMiddlewareAdmin(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		role    string
+		wantErr bool
+	}{
+		{name: "admin", role: roleAdmin},
+		{name: "member", role: roleMember, wantErr: true},
+		{name: "logged out", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			if test.role != "" {
+				logIn(t, s, addUser(t, s, "alice", test.role, true))
+			}
+
+			ran := false
+			handler := middlewareAdmin(func(s *state, cmd command, user database.User) error {
+				ran = true
+				return nil
+			})
+
+			err := handler(s, command{name: "prune"})
+			if (err != nil) != test.wantErr || ran == test.wantErr {
+				t.Errorf("prune as %v = %v and ran %v, want error %v", test.name, err, ran, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestPromoteAndDemote(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		handler  func(*state, command, database.User) error
+		target   string
+		wantErr  bool
+		wantRole string
+	}{
+		{name: "promote member", handler: handlerPromote, target: "bob", wantRole: roleAdmin},
+		{name: "promote admin", handler: handlerPromote, target: "alice", wantErr: true, wantRole: roleAdmin},
+		{name: "demote member", handler: handlerDemote, target: "bob", wantErr: true, wantRole: roleMember},
+		{name: "demote last admin", handler: handlerDemote, target: "alice", wantErr: true, wantRole: roleAdmin},
+		{name: "promote unknown user", handler: handlerPromote, target: "carol", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			alice := addUser(t, s, "alice", roleAdmin, true)
+			addUser(t, s, "bob", roleMember, true)
+
+			var err error
+			captureOutput(t, func() {
+				err = test.handler(s, command{arguments: []string{test.target}}, alice)
+			})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("%v = %v, want error %v", test.name, err, test.wantErr)
+			}
+
+			if test.wantRole == "" {
+				return
+			}
+			user, err := s.db.GetUser(context.Background(), test.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Role != test.wantRole {
+				t.Errorf("%v is %v, want %v", test.target, user.Role, test.wantRole)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	s := newTestState(t)
+	alice := addUser(t, s, "alice", roleAdmin, true)
+	bob := addUser(t, s, "bob", roleMember, true)
+	carol := addUser(t, s, "carol", roleMember, true)
+
+	shared := addFeed(t, s, bob, "shared", "https://example.com/shared")
+	follow(t, s, bob, shared)
+	follow(t, s, carol, shared)
+	addFeed(t, s, bob, "own", "https://example.com/own")
+
+	withInput(t, "no")
+	captureOutput(t, func() {
+		if err := handlerDeleteUser(s, command{name: "deleteuser", arguments: []string{"bob"}}, alice); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if _, err := s.db.GetUser(context.Background(), "bob"); err != nil {
+		t.Fatalf("bob is gone without confirming: %v", err)
+	}
+
+	withInput(t, "yes")
+	captureOutput(t, func() {
+		if err := handlerDeleteUser(s, command{name: "deleteuser", arguments: []string{"bob"}}, alice); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if _, err := s.db.GetUser(context.Background(), "bob"); err == nil {
+		t.Error("bob still exists")
+	}
+
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feeds) != 1 || feeds[0].Name != "shared" || feeds[0].UserID != carol.ID {
+		t.Errorf("feeds left are %+v, want shared handed to carol", feeds)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(home, ".gator", "backups", "gator-deleteuser-*"))
+	if len(backups) != 1 {
+		t.Errorf("deleteuser wrote %d backups, want 1", len(backups))
+	}
+
+	if err := handlerDeleteUser(s, command{name: "deleteuser", arguments: []string{"--yes", "alice"}}, alice); err == nil {
+		t.Error("the last admin was deleted")
+	}
+}
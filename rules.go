@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+var ruleFields = []string{"title", "description", "url", "feed"}
+
+var ruleActions = []string{"hide", "read", "save", "tag"}
+
+// rulePost holds the post fields a filter rule can match on.
+type rulePost struct {
+	ID          uuid.UUID
+	Title       string
+	Description string
+	Url         string
+	FeedName    string
+	FeedUrl     string
+}
+
+type compiledRule struct {
+	rule  database.FilterRule
+	regex *regexp.Regexp
+}
+
+func compileRule(rule database.FilterRule) (compiledRule, error) {
+
+	compiled := compiledRule{rule: rule}
+
+	if rule.IsRegex {
+		regex, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("invalid pattern %q: %w", rule.Pattern, err)
+		}
+		compiled.regex = regex
+	}
+
+	return compiled, nil
+}
+
+// compileRules compiles the rules that can be. A stored pattern that does
+// not compile, e.g. from a database edited by hand, is logged and skipped
+// so that it does not hold up the user's other rules.
+func compileRules(logger *slog.Logger, rules []database.FilterRule) []compiledRule {
+
+	compiled := make([]compiledRule, 0, len(rules))
+
+	for _, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			logger.Warn("skipping invalid rule", "rule_id", rule.ID, "user_id", rule.UserID, "err", err)
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+
+	return compiled
+}
+
+// matches reports whether the rule's field matches the post. Substring
+// patterns are case-insensitive, and the feed field matches both the feed
+// name and its url.
+func (c compiledRule) matches(post rulePost) bool {
+
+	var values []string
+
+	switch c.rule.Field {
+	case "title":
+		values = []string{post.Title}
+	case "description":
+		values = []string{post.Description}
+	case "url":
+		values = []string{post.Url}
+	case "feed":
+		values = []string{post.FeedName, post.FeedUrl}
+	}
+
+	for _, value := range values {
+		if c.regex != nil {
+			if c.regex.MatchString(value) {
+				return true
+			}
+		} else if strings.Contains(strings.ToLower(value), strings.ToLower(c.rule.Pattern)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func applyRuleAction(s *state, rule database.FilterRule, postID uuid.UUID) error {
+
+	switch rule.Action {
+	case "hide":
+		return s.db.HidePost(context.Background(), database.HidePostParams{UserID: rule.UserID, PostID: postID})
+	case "read":
+		return s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{UserID: rule.UserID, PostID: postID})
+	case "save":
+		return s.db.SavePost(context.Background(), database.SavePostParams{UserID: rule.UserID, PostID: postID})
+	case "tag":
+		return tagPost(s, rule.UserID, postID, rule.Tag.String)
+	}
+
+	return fmt.Errorf("unknown rule action %v", rule.Action)
+}
+
+// applyRules runs the action of every rule matching post and returns how
+// many rules matched.
+func applyRules(s *state, rules []compiledRule, post rulePost) (int, error) {
+
+	matched := 0
+
+	for _, rule := range rules {
+		if !rule.matches(post) {
+			continue
+		}
+		if err := applyRuleAction(s, rule.rule, post.ID); err != nil {
+			return matched, err
+		}
+		matched++
+	}
+
+	return matched, nil
+}
+
+func handlerAddRule(s *state, cmd command, user database.User) error {
+
+	flags := newFlagSet(cmd)
+	isRegex := flags.Bool("regex", false, "treat the pattern as a regular expression")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	arguments := flags.Args()
+	if len(arguments) < 3 {
+		return fmt.Errorf("usage: addrule [--regex] <title|description|url|feed> <pattern> <hide|read|save|tag> [tag]")
+	}
+
+	field := arguments[0]
+	pattern := arguments[1]
+	action := arguments[2]
+
+	if !slices.Contains(ruleFields, field) {
+		return fmt.Errorf("unknown field %v, expected one of %v", field, strings.Join(ruleFields, ", "))
+	}
+
+	if !slices.Contains(ruleActions, action) {
+		return fmt.Errorf("unknown action %v, expected one of %v", action, strings.Join(ruleActions, ", "))
+	}
+
+	if _, err := compileRule(database.FilterRule{Pattern: pattern, IsRegex: *isRegex}); err != nil {
+		return err
+	}
+
+	var tag sql.NullString
+
+	if action == "tag" {
+		if len(arguments) < 4 || normalizeTag(arguments[3]) == "" {
+			return fmt.Errorf("no tag given for tag action")
+		}
+		tag = sql.NullString{String: normalizeTag(arguments[3]), Valid: true}
+	}
+
+	parameter := database.CreateFilterRuleParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Field:     field,
+		Pattern:   pattern,
+		IsRegex:   *isRegex,
+		Action:    action,
+		Tag:       tag,
+	}
+
+	rule, err := s.db.CreateFilterRule(context.Background(), parameter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rule was created: %v\n", rule.ID)
+
+	return nil
+}
+
+func handlerRules(s *state, _ command, user database.User) error {
+
+	rules, err := s.db.GetFilterRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	if s.format == formatJSON {
+		type ruleOutput struct {
+			ID      uuid.UUID `json:"id"`
+			Field   string    `json:"field"`
+			Pattern string    `json:"pattern"`
+			IsRegex bool      `json:"is_regex"`
+			Action  string    `json:"action"`
+			Tag     string    `json:"tag,omitempty"`
+		}
+		output := make([]ruleOutput, 0, len(rules))
+		for _, rule := range rules {
+			output = append(output, ruleOutput{
+				ID:      rule.ID,
+				Field:   rule.Field,
+				Pattern: rule.Pattern,
+				IsRegex: rule.IsRegex,
+				Action:  rule.Action,
+				Tag:     rule.Tag.String,
+			})
+		}
+		return printJSON(output)
+	}
+
+	for _, rule := range rules {
+
+		kind := "contains"
+		if rule.IsRegex {
+			kind = "matches"
+		}
+
+		action := rule.Action
+		if rule.Tag.Valid {
+			action = fmt.Sprintf("%v %v", action, rule.Tag.String)
+		}
+
+		fmt.Printf("%v: %v %v %q -> %v\n", rule.ID, rule.Field, kind, rule.Pattern, action)
+	}
+
+	return nil
+}
+
+func handlerDeleteRule(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) <= 0 {
+		return fmt.Errorf("no rule id given")
+	}
+
+	id, err := uuid.Parse(cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	parameter := database.DeleteFilterRuleParams{
+		ID:     id,
+		UserID: user.ID,
+	}
+
+	deleted, err := s.db.DeleteFilterRule(context.Background(), parameter)
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("rule does not exist")
+	}
+
+	fmt.Println("Rule has been deleted")
+
+	return nil
+}
+
+// handlerApplyRules runs the current user's rules against every post
+// already stored for the feeds they follow.
+func handlerApplyRules(s *state, _ command, user database.User) error {
+
+	rules, err := s.db.GetFilterRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	compiled := compileRules(s.logger, rules)
+
+	posts, err := s.db.GetFollowedPostsForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	matchedPosts := 0
+
+	for _, post := range posts {
+
+		matched, err := applyRules(s, compiled, rulePost{
+			ID:          post.ID,
+			Title:       post.Title,
+			Description: post.Description.String,
+			Url:         post.Url,
+			FeedName:    post.FeedName,
+			FeedUrl:     post.FeedUrl,
+		})
+		if err != nil {
+			return err
+		}
+
+		if matched > 0 {
+			matchedPosts++
+		}
+	}
+
+	fmt.Printf("Rules matched %d of %d posts\n", matchedPosts, len(posts))
+
+	return nil
+}
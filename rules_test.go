@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/config"
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestAddRule(t *testing.T) {
+
+	tests := []struct {
+		arguments string
+		wantErr   bool
+	}{
+		{arguments: "title go hide"},
+		{arguments: "--regex url ^https://example read"},
+		{arguments: "feed example tag news"},
+		{arguments: "--regex title ( hide", wantErr: true},
+		{arguments: "author go hide", wantErr: true},
+		{arguments: "title go delete", wantErr: true},
+		{arguments: "title go tag", wantErr: true},
+		{arguments: "title go", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.arguments, func(t *testing.T) {
+
+			s := newTestState(t)
+			user := addUser(t, s, "alice", roleMember, true)
+
+			var err error
+			captureOutput(t, func() {
+				err = handlerAddRule(s, command{name: "addrule", arguments: strings.Fields(test.arguments)}, user)
+			})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("addrule %v = %v, want error %v", test.arguments, err, test.wantErr)
+			}
+
+			rules, err := s.db.GetFilterRulesForUser(context.Background(), user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantRules := 1
+			if test.wantErr {
+				wantRules = 0
+			}
+			if len(rules) != wantRules {
+				t.Errorf("addrule %v stored %d rules, want %d", test.arguments, len(rules), wantRules)
+			}
+		})
+	}
+}
+
+// addRule stores a rule as is, without the checks of addrule, like one
+// written by an older gator or by hand.
+func addRule(t *testing.T, s *state, user database.User, pattern string, isRegex bool, action string) {
+
+	t.Helper()
+
+	_, err := s.db.CreateFilterRule(context.Background(), database.CreateFilterRuleParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Field:     "title",
+		Pattern:   pattern,
+		IsRegex:   isRegex,
+		Action:    action,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvalidStoredRuleIsSkipped(t *testing.T) {
+
+	tests := []struct {
+		name string
+		run  func(s *state, user database.User) error
+	}{
+		{name: "agg", run: func(s *state, _ database.User) error {
+			a := newTestAggregator(t, s, 1, config.FetchConfig{})
+			if err := a.aggregate(context.Background()); err != nil {
+				return err
+			}
+			if a.stats.fetches != 1 {
+				t.Errorf("agg fetched %d feeds, want 1", a.stats.fetches)
+			}
+			return nil
+		}},
+		{name: "applyrules", run: func(s *state, user database.User) error {
+			a := newTestAggregator(t, s, 1, config.FetchConfig{})
+			if err := a.aggregate(context.Background()); err != nil {
+				return err
+			}
+			// Undo what agg did to see applyrules do it again.
+			if _, err := s.store.DB.Exec("DELETE FROM post_states"); err != nil {
+				return err
+			}
+			var err error
+			captureOutput(t, func() {
+				err = handlerApplyRules(s, command{name: "applyrules"}, user)
+			})
+			return err
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			user := addUser(t, s, "alice", roleMember, true)
+
+			server := newFeedServer(t, 0)
+			follow(t, s, user, addFeed(t, s, user, "a", server.URL+"/a"))
+
+			addRule(t, s, user, "(", true, "hide")
+			addRule(t, s, user, "post", false, "save")
+
+			if err := test.run(s, user); err != nil {
+				t.Fatal(err)
+			}
+
+			states, err := s.db.GetPostStates(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 1 || !states[0].SavedAt.Valid || states[0].HiddenAt != (sql.NullTime{}) {
+				t.Errorf("post states are %+v, want the post saved by the valid rule", states)
+			}
+		})
+	}
+}
@@ -25,4 +25,73 @@ SELECT * FROM feed_follows
 WHERE user_id = $1; 
 
 -- name: UnfollowFeed :exec
-DELETE FROM feed_follows WHERE user_id = $1 AND feed_id = $2;
\ No newline at end of file
+DELETE FROM feed_follows WHERE user_id = $1 AND feed_id = $2;
+
+-- name: GetFollowedFeedsForUser :many
+SELECT
+    feeds.*,
+    COALESCE(feed_follows.title, feeds.name) AS display_name,
+    folders.name AS folder_name
+FROM feed_follows
+INNER JOIN feeds
+ON feed_follows.feed_id = feeds.id
+LEFT JOIN folders
+ON feed_follows.folder_id = folders.id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name NULLS FIRST, display_name;
+
+-- name: SetFeedFollowFolder :execrows
+UPDATE feed_follows
+SET folder_id = $3, updated_at = NOW()
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: SetFeedFollowTitle :execrows
+UPDATE feed_follows
+SET title = $3, updated_at = NOW()
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: GetFeedFollows :many
+SELECT * FROM feed_follows
+ORDER BY created_at;
+
+-- name: RestoreFeedFollow :execrows
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder_id, title)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetFeedFollowsForFeed :many
+SELECT * FROM feed_follows
+WHERE feed_id = $1
+ORDER BY created_at;
+
+-- name: GetFeedsWithUnreadCountForUser :many
+SELECT
+    feeds.id,
+    feeds.url,
+    COALESCE(feed_follows.title, feeds.name) AS display_name,
+    folders.name AS folder_name,
+    COUNT(posts.id) AS unread_count
+FROM feed_follows
+INNER JOIN feeds
+ON feeds.id = feed_follows.feed_id
+LEFT JOIN folders
+ON folders.id = feed_follows.folder_id
+LEFT JOIN posts
+ON posts.feed_id = feeds.id
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.user_id = $1
+    AND (post_states.read_at IS NOT NULL OR post_states.hidden_at IS NOT NULL)
+)
+WHERE feed_follows.user_id = $1
+GROUP BY feeds.id, feeds.url, feeds.name, feed_follows.title, folders.name
+ORDER BY folders.name NULLS FIRST, display_name;
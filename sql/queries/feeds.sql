@@ -24,10 +24,57 @@ WHERE id = $1;
 
 -- name: MarkFeedFetched :exec
 UPDATE feeds
-SET last_fetched_at = NOW(), updated_at = NOW()
-WHERE id = $1;
+SET last_fetched_at = sqlc.arg('fetched_at')::timestamp, next_fetch_at = NULL, updated_at = NOW()
+WHERE id = sqlc.arg('id');
 
 -- name: GetNextFeedToFetch :one
 SELECT * FROM feeds
+WHERE next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg('now')::timestamp
 ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+
+-- name: DeleteAllFeeds :exec
+DELETE FROM feeds;
+
+-- name: DeleteFeedsForUser :exec
+DELETE FROM feeds
+WHERE user_id = $1;
+
+-- name: RestoreFeed :execrows
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UpdateFeed :one
+UPDATE feeds
+SET name = $2, url = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetFeedOwner :exec
+UPDATE feeds
+SET user_id = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: DelayFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetFeedsForUser :many
+SELECT * FROM feeds
+WHERE user_id = $1
+ORDER BY created_at;
@@ -0,0 +1,49 @@
+-- name: CreateFilterRule :one
+INSERT INTO filter_rules (id, created_at, updated_at, user_id, field, pattern, is_regex, action, tag)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: GetFilterRulesForUser :many
+SELECT * FROM filter_rules
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetFilterRulesForFeed :many
+SELECT filter_rules.* FROM filter_rules
+INNER JOIN feed_follows
+ON feed_follows.user_id = filter_rules.user_id
+WHERE feed_follows.feed_id = $1
+ORDER BY filter_rules.created_at;
+
+-- name: DeleteFilterRule :execrows
+DELETE FROM filter_rules
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetFilterRules :many
+SELECT * FROM filter_rules
+ORDER BY created_at;
+
+-- name: RestoreFilterRule :execrows
+INSERT INTO filter_rules (id, created_at, updated_at, user_id, field, pattern, is_regex, action, tag)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+ON CONFLICT DO NOTHING;
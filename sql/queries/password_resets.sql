@@ -0,0 +1,17 @@
+-- name: CreatePasswordReset :exec
+INSERT INTO password_resets (token_hash, user_id, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: GetUserFromPasswordReset :one
+SELECT users.* FROM password_resets
+INNER JOIN users ON password_resets.user_id = users.id
+WHERE password_resets.token_hash = $1 AND password_resets.expires_at > $2;
+
+-- name: DeletePasswordResetsForUser :exec
+DELETE FROM password_resets
+WHERE user_id = $1;
@@ -0,0 +1,43 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_states (user_id, post_id, created_at, updated_at, read_at)
+VALUES ($1, $2, NOW(), NOW(), NOW())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET read_at = NOW(), updated_at = NOW();
+
+-- name: MarkPostUnread :exec
+UPDATE post_states
+SET read_at = NULL, updated_at = NOW()
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: SavePost :exec
+INSERT INTO post_states (user_id, post_id, created_at, updated_at, saved_at)
+VALUES ($1, $2, NOW(), NOW(), NOW())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET saved_at = NOW(), updated_at = NOW();
+
+-- name: UnsavePost :exec
+UPDATE post_states
+SET saved_at = NULL, updated_at = NOW()
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: HidePost :exec
+INSERT INTO post_states (user_id, post_id, created_at, updated_at, hidden_at)
+VALUES ($1, $2, NOW(), NOW(), NOW())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET hidden_at = NOW(), updated_at = NOW();
+
+-- name: GetPostStates :many
+SELECT * FROM post_states;
+
+-- name: RestorePostState :execrows
+INSERT INTO post_states (user_id, post_id, created_at, updated_at, read_at, saved_at, hidden_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT DO NOTHING;
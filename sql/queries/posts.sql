@@ -13,9 +13,117 @@ VALUES (
 RETURNING *;
 
 -- name: GetPostsForUser :many
-SELECT posts.* FROM posts
+SELECT
+    posts.*,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name
+FROM posts
 INNER JOIN feed_follows
 ON feed_follows.feed_id = posts.feed_id 
-WHERE feed_follows.user_id = $1
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('folder_id')::uuid IS NULL OR feed_follows.folder_id = sqlc.narg('folder_id'))
+AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    INNER JOIN tags
+    ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id
+    AND tags.user_id = sqlc.arg('user_id')
+    AND tags.name = sqlc.narg('tag')
+))
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.user_id = sqlc.arg('user_id')
+    AND post_states.hidden_at IS NOT NULL
+)
 ORDER BY posts.created_at DESC
-LIMIT $2; 
\ No newline at end of file
+LIMIT sqlc.arg('limit');
+
+-- name: GetFollowedPostsForUser :many
+SELECT
+    posts.*,
+    feeds.name AS feed_name,
+    feeds.url AS feed_url
+FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY posts.created_at;
+
+-- name: GetPostFromID :one
+SELECT * FROM posts
+WHERE id = $1;
+
+-- name: GetPostByURL :one
+SELECT * FROM posts
+WHERE url = $1;
+
+-- name: GetPrunablePostsForFeed :many
+SELECT posts.* FROM posts
+WHERE posts.feed_id = sqlc.arg('feed_id')
+AND (
+    COALESCE(posts.published_at, posts.created_at) < sqlc.narg('older_than')::timestamp
+    OR posts.id NOT IN (
+        SELECT newest.id FROM posts AS newest
+        WHERE newest.feed_id = sqlc.arg('feed_id')
+        ORDER BY COALESCE(newest.published_at, newest.created_at) DESC
+        LIMIT sqlc.narg('max_posts')::int
+    )
+)
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.saved_at IS NOT NULL
+)
+AND NOT (
+    posts.created_at >= sqlc.arg('unread_since')::timestamp
+    AND EXISTS (
+        SELECT 1 FROM feed_follows
+        LEFT JOIN post_states
+        ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+        WHERE feed_follows.feed_id = posts.feed_id
+        AND post_states.read_at IS NULL
+    )
+)
+ORDER BY COALESCE(posts.published_at, posts.created_at) ASC;
+
+-- name: DeletePosts :exec
+DELETE FROM posts
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: DeleteAllPosts :exec
+DELETE FROM posts;
+
+-- name: GetPosts :many
+SELECT * FROM posts
+ORDER BY created_at;
+
+-- name: RestorePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetPostsForFeedForUser :many
+SELECT
+    posts.*,
+    post_states.read_at,
+    post_states.saved_at
+FROM posts
+LEFT JOIN post_states
+ON post_states.post_id = posts.id
+AND post_states.user_id = $1
+WHERE posts.feed_id = $2
+AND post_states.hidden_at IS NULL
+ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC;
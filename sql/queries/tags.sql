@@ -0,0 +1,71 @@
+-- name: CreateTag :one
+INSERT INTO tags (id, created_at, updated_at, user_id, name)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (user_id, name)
+DO UPDATE SET updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: GetTag :one
+SELECT * FROM tags
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetTagsForUser :many
+SELECT
+    tags.name,
+    COUNT(post_tags.post_id) AS post_count
+FROM tags
+LEFT JOIN post_tags
+ON post_tags.tag_id = tags.id
+WHERE tags.user_id = $1
+GROUP BY tags.id, tags.name
+ORDER BY tags.name;
+
+-- name: TagPost :exec
+INSERT INTO post_tags (tag_id, post_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (tag_id, post_id) DO NOTHING;
+
+-- name: UntagPost :exec
+DELETE FROM post_tags
+WHERE tag_id = $1 AND post_id = $2;
+
+-- name: DeleteTagIfUnused :exec
+DELETE FROM tags
+WHERE id = $1
+AND NOT EXISTS (
+    SELECT 1 FROM post_tags
+    WHERE post_tags.tag_id = tags.id
+);
+
+-- name: GetTags :many
+SELECT * FROM tags
+ORDER BY created_at;
+
+-- name: GetPostTags :many
+SELECT * FROM post_tags;
+
+-- name: RestoreTag :execrows
+INSERT INTO tags (id, created_at, updated_at, user_id, name)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RestorePostTag :execrows
+INSERT INTO post_tags (tag_id, post_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE folders (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(user_id, name)
+);
+
+ALTER TABLE feed_follows ADD COLUMN folder_id TEXT REFERENCES folders(id) ON DELETE SET NULL;
+
+-- +goose Down
+-- SQLite cannot drop a column that is part of a foreign key, so the table
+-- is rebuilt without it.
+CREATE TABLE feed_follows_old (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL,
+    feed_id TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE,
+    UNIQUE(user_id, feed_id)
+);
+INSERT INTO feed_follows_old (id, created_at, updated_at, user_id, feed_id)
+SELECT id, created_at, updated_at, user_id, feed_id FROM feed_follows;
+DROP TABLE feed_follows;
+ALTER TABLE feed_follows_old RENAME TO feed_follows;
+DROP TABLE folders;
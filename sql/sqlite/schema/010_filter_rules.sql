@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE filter_rules (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL,
+    field TEXT NOT NULL,
+    pattern TEXT NOT NULL,
+    is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+    action TEXT NOT NULL,
+    tag TEXT,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE post_states ADD COLUMN hidden_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE post_states DROP COLUMN hidden_at;
+DROP TABLE filter_rules;
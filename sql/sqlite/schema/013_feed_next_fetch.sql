@@ -0,0 +1,7 @@
+-- +goose Up
+-- Set when a server asks gator to come back later with Retry-After; the
+-- feed is not fetched again before then.
+ALTER TABLE feeds ADD COLUMN next_fetch_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN next_fetch_at;
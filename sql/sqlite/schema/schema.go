@@ -0,0 +1,8 @@
+// Package schema embeds the SQLite variant of the goose migrations. Each
+// file mirrors the Postgres migration with the same version in sql/schema.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
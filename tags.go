@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// tagPost attaches a tag to a post for a user, creating the tag if needed.
+func tagPost(s *state, userID uuid.UUID, postID uuid.UUID, name string) error {
+
+	parameter := database.CreateTagParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		Name:      normalizeTag(name),
+	}
+
+	tag, err := s.db.CreateTag(context.Background(), parameter)
+	if err != nil {
+		return err
+	}
+
+	secondParameter := database.TagPostParams{
+		TagID:  tag.ID,
+		PostID: postID,
+	}
+
+	return s.db.TagPost(context.Background(), secondParameter)
+}
+
+func handlerTag(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) < 2 {
+		return fmt.Errorf("usage: tag <post id or url> <tag...>")
+	}
+
+	post, err := getPostFromArgument(s, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	for _, name := range cmd.arguments[1:] {
+		if normalizeTag(name) == "" {
+			continue
+		}
+		if err := tagPost(s, user.ID, post.ID, name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Tagged %v\n", post.Title)
+
+	return nil
+}
+
+func handlerUntag(s *state, cmd command, user database.User) error {
+
+	if len(cmd.arguments) < 2 {
+		return fmt.Errorf("usage: untag <post id or url> <tag...>")
+	}
+
+	post, err := getPostFromArgument(s, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	for _, name := range cmd.arguments[1:] {
+
+		parameter := database.GetTagParams{
+			UserID: user.ID,
+			Name:   normalizeTag(name),
+		}
+
+		tag, err := s.db.GetTag(context.Background(), parameter)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		secondParameter := database.UntagPostParams{
+			TagID:  tag.ID,
+			PostID: post.ID,
+		}
+
+		if err := s.db.UntagPost(context.Background(), secondParameter); err != nil {
+			return err
+		}
+
+		if err := s.db.DeleteTagIfUnused(context.Background(), tag.ID); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Untagged %v\n", post.Title)
+
+	return nil
+}
+
+func handlerTags(s *state, _ command, user database.User) error {
+
+	tags, err := s.db.GetTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	if s.format == formatJSON {
+		type tagOutput struct {
+			Name      string `json:"name"`
+			PostCount int64  `json:"post_count"`
+		}
+		output := make([]tagOutput, 0, len(tags))
+		for _, tag := range tags {
+			output = append(output, tagOutput{Name: tag.Name, PostCount: tag.PostCount})
+		}
+		return printJSON(output)
+	}
+
+	for _, tag := range tags {
+		fmt.Printf("%v (%d)\n", tag.Name, tag.PostCount)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestTagAndUntag(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		tag      string
+		untag    string
+		wantTags []string
+		wantErr  bool
+	}{
+		{name: "several tags", tag: "Go news", wantTags: []string{"go", "news"}},
+		{name: "untag several", tag: "go news later", untag: "GO later", wantTags: []string{"news"}},
+		{name: "untag unknown tag", tag: "go", untag: "rust", wantTags: []string{"go"}},
+		{name: "tag without a tag", tag: "", wantErr: true},
+		{name: "untag without a tag", tag: "go", untag: " ", wantErr: true, wantTags: []string{"go"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := newTestState(t)
+			user := addUser(t, s, "alice", roleMember, true)
+			feed := addFeed(t, s, user, "a", "https://example.com/a")
+
+			post, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
+				ID:     uuid.New(),
+				Title:  "post",
+				Url:    "https://example.com/a/post",
+				FeedID: feed.ID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var tagErr, untagErr error
+			captureOutput(t, func() {
+				tagErr = handlerTag(s, command{name: "tag", arguments: append([]string{post.Url}, strings.Fields(test.tag)...)}, user)
+				if test.untag != "" {
+					untagErr = handlerUntag(s, command{name: "untag", arguments: append([]string{post.ID.String()}, strings.Fields(test.untag)...)}, user)
+				}
+			})
+			if err := errors.Join(tagErr, untagErr); (err != nil) != test.wantErr {
+				t.Fatalf("tag %q, untag %q = %v, want error %v", test.tag, test.untag, err, test.wantErr)
+			}
+
+			tags, err := s.db.GetTagsForUser(context.Background(), user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, tag := range tags {
+				names = append(names, tag.Name)
+			}
+			slices.Sort(names)
+
+			if !slices.Equal(names, test.wantTags) {
+				t.Errorf("tags are %q, want %q", names, test.wantTags)
+			}
+		})
+	}
+}
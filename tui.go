@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Omorfii/aggregator/internal/database"
+	"github.com/Omorfii/aggregator/internal/tui"
+)
+
+func handlerTUI(s *state, cmd command, user database.User) error {
+
+	flags := newFlagSet(cmd)
+	refresh := flags.Duration("refresh", 5*time.Second, "how often to reload feeds and posts")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return err
+	}
+
+	if *refresh <= 0 {
+		return fmt.Errorf("--refresh must be positive")
+	}
+
+	return tui.Run(s.db, user, *refresh)
+}